@@ -208,7 +208,9 @@ async function loadOrder(orderId) {
     customerPhone.value = ''
   }
   
+  // Keep backend fields (tax rate, discounts) so they survive re-saving
   products.value = (order.items || []).map((item, i) => ({
+    ...item,
     id: item.id || (Date.now() + i).toString(),
     product_name: item.product_name,
     oem_number: item.oem_number || '-',
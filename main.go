@@ -106,6 +106,14 @@ func main() {
 	bindProductFunctions(w)
 	bindStockFunctions(w)
 	bindSettingsFunctions(w)
+	bindCurrencyFunctions(w)
+	bindStockCountFunctions(w)
+	bindLocationFunctions(w)
+	bindPurchaseFunctions(w)
+	bindReceivableFunctions(w)
+	bindCashFunctions(w)
+	bindExpenseFunctions(w)
+	bindVehicleFunctions(w)
 
 	w.Navigate(fmt.Sprintf("http://127.0.0.1:%d/", port))
 	w.Run()
@@ -121,8 +129,13 @@ func bindOrderFunctions(w webview2.WebView) {
 	w.Bind("loadOrdersFromBleve", loadOrdersFromBleve)
 	w.Bind("loadOrderById", loadOrderById)
 	w.Bind("deleteOrderFromBleve", deleteOrderFromBleve)
+	w.Bind("setOrderStatus", setOrderStatus)
+	w.Bind("deliverOrder", deliverOrder)
+	w.Bind("getProductReservations", getProductReservations)
 	w.Bind("searchOrders", searchOrders)
 	w.Bind("searchOrdersAdvanced", searchOrdersAdvanced)
+	w.Bind("getSalesReport", getSalesReport)
+	w.Bind("getProfitabilityReport", getProfitabilityReport)
 }
 
 // bindCustomerFunctions binds customer-related functions to WebView
@@ -146,6 +159,13 @@ func bindProductFunctions(w webview2.WebView) {
 	w.Bind("getCategories", getCategories)
 	w.Bind("getBrands", getBrands)
 	w.Bind("getUnits", getUnits)
+	w.Bind("updateProductPurchasePrice", updateProductPurchasePrice)
+	w.Bind("setProductLotTracking", setProductLotTracking)
+	w.Bind("setProductSerialTracking", setProductSerialTracking)
+	w.Bind("setProductUnits", setProductUnits)
+	w.Bind("findSerialNumber", findSerialNumber)
+	w.Bind("listProductSerials", listProductSerials)
+	w.Bind("returnSerial", returnSerial)
 }
 
 // bindStockFunctions binds stock management functions to WebView
@@ -155,14 +175,126 @@ func bindStockFunctions(w webview2.WebView) {
 	w.Bind("bulkStockIn", bulkStockIn)
 	w.Bind("bulkStockOut", bulkStockOut)
 	w.Bind("getStockMovements", getStockMovements)
+	w.Bind("reverseStockMovement", reverseStockMovement)
 	w.Bind("getCriticalStockProducts", getCriticalStockProducts)
 	w.Bind("getStockReport", getStockReport)
+	w.Bind("getInventoryValuation", getInventoryValuation)
+	w.Bind("getCostOfGoodsSold", getCostOfGoodsSold)
+	w.Bind("getStockLevelsAt", getStockLevelsAt)
+	w.Bind("getExpiryReport", getExpiryReport)
+	w.Bind("getDemandForecast", getDemandForecast)
+	w.Bind("reconcileStock", reconcileStock)
 }
 
 // bindSettingsFunctions binds settings functions to WebView
 func bindSettingsFunctions(w webview2.WebView) {
 	w.Bind("setDeveloperMode", setDeveloperMode)
 	w.Bind("getDeveloperMode", getDeveloperMode)
+	w.Bind("getTaxRates", getTaxRates)
+	w.Bind("setCategoryTaxRate", setCategoryTaxRate)
+	w.Bind("getValuationMethod", getValuationMethod)
+	w.Bind("setValuationMethod", setValuationMethod)
+}
+
+// bindStockCountFunctions binds stock adjustment and stock-take functions to WebView
+func bindStockCountFunctions(w webview2.WebView) {
+	w.Bind("adjustStock", adjustStock)
+	w.Bind("startStockCount", startStockCount)
+	w.Bind("listStockCounts", listStockCounts)
+	w.Bind("getStockCount", getStockCount)
+	w.Bind("setStockCountLine", setStockCountLine)
+	w.Bind("removeStockCountLine", removeStockCountLine)
+	w.Bind("commitStockCount", commitStockCount)
+	w.Bind("cancelStockCount", cancelStockCount)
+}
+
+// bindLocationFunctions binds stock location functions to WebView
+func bindLocationFunctions(w webview2.WebView) {
+	w.Bind("listLocations", listLocations)
+	w.Bind("createLocation", createLocation)
+	w.Bind("updateLocation", updateLocation)
+	w.Bind("deleteLocation", deleteLocation)
+	w.Bind("transferStock", transferStock)
+	w.Bind("getProductLocationStock", getProductLocationStock)
+	w.Bind("setLocationCriticalStock", setLocationCriticalStock)
+	w.Bind("getCriticalStockAtLocation", getCriticalStockAtLocation)
+	w.Bind("getCriticalStockByLocation", getCriticalStockByLocation)
+}
+
+// bindPurchaseFunctions binds supplier and purchase order functions to WebView
+func bindPurchaseFunctions(w webview2.WebView) {
+	w.Bind("setCustomerSupplier", setCustomerSupplier)
+	w.Bind("listSuppliers", listSuppliers)
+	w.Bind("createPurchaseOrder", createPurchaseOrder)
+	w.Bind("updatePurchaseOrder", updatePurchaseOrder)
+	w.Bind("getPurchaseOrder", getPurchaseOrder)
+	w.Bind("listPurchaseOrders", listPurchaseOrders)
+	w.Bind("closePurchaseOrder", closePurchaseOrder)
+	w.Bind("deletePurchaseOrder", deletePurchaseOrder)
+	w.Bind("receiveGoods", receiveGoods)
+	w.Bind("setProductReorder", setProductReorder)
+	w.Bind("getReorderPlan", getReorderPlan)
+	w.Bind("createReorderPurchaseOrders", createReorderPurchaseOrders)
+	w.Bind("exportReorderPlan", exportReorderPlan)
+}
+
+// bindReceivableFunctions binds customer payment and receivables functions to WebView
+func bindReceivableFunctions(w webview2.WebView) {
+	w.Bind("createPayment", createPayment)
+	w.Bind("updatePayment", updatePayment)
+	w.Bind("deletePayment", deletePayment)
+	w.Bind("listPayments", listPayments)
+	w.Bind("getOrderPayments", getOrderPayments)
+	w.Bind("getCustomerLedger", getCustomerLedger)
+	w.Bind("getCustomerStatement", getCustomerStatement)
+	w.Bind("getAgedReceivables", getAgedReceivables)
+}
+
+// bindCashFunctions binds cash book functions to WebView
+func bindCashFunctions(w webview2.WebView) {
+	w.Bind("getCashCategories", getCashCategories)
+	w.Bind("createCashEntry", createCashEntry)
+	w.Bind("updateCashEntry", updateCashEntry)
+	w.Bind("deleteCashEntry", deleteCashEntry)
+	w.Bind("setCashOpeningBalance", setCashOpeningBalance)
+	w.Bind("closeCashDay", closeCashDay)
+	w.Bind("reopenCashDay", reopenCashDay)
+	w.Bind("getCashReport", getCashReport)
+	w.Bind("printCashReport", printCashReport)
+}
+
+// bindExpenseFunctions binds expense and profit and loss functions to WebView
+func bindExpenseFunctions(w webview2.WebView) {
+	w.Bind("getExpenseCategories", getExpenseCategories)
+	w.Bind("createExpense", createExpense)
+	w.Bind("updateExpense", updateExpense)
+	w.Bind("deleteExpense", deleteExpense)
+	w.Bind("listExpenses", listExpenses)
+	w.Bind("getProfitLossReport", getProfitLossReport)
+}
+
+// bindVehicleFunctions binds vehicle registry functions to WebView
+func bindVehicleFunctions(w webview2.WebView) {
+	w.Bind("createVehicle", createVehicle)
+	w.Bind("updateVehicle", updateVehicle)
+	w.Bind("deleteVehicle", deleteVehicle)
+	w.Bind("getVehicle", getVehicle)
+	w.Bind("listVehicles", listVehicles)
+	w.Bind("searchVehicles", searchVehicles)
+	w.Bind("getVehicleHistory", getVehicleHistory)
+	w.Bind("getServiceIntervals", getServiceIntervals)
+	w.Bind("setServiceInterval", setServiceInterval)
+	w.Bind("getMaintenanceReminders", getMaintenanceReminders)
+}
+
+// bindCurrencyFunctions binds currency and exchange rate functions to WebView
+func bindCurrencyFunctions(w webview2.WebView) {
+	w.Bind("getCurrencies", getCurrencies)
+	w.Bind("setBaseCurrency", setBaseCurrency)
+	w.Bind("listExchangeRates", listExchangeRates)
+	w.Bind("saveExchangeRate", saveExchangeRate)
+	w.Bind("deleteExchangeRate", deleteExchangeRate)
+	w.Bind("importExchangeRates", importExchangeRates)
 }
 
 // =============================================================================
@@ -221,6 +353,16 @@ func saveOrderToBleve(orderJSON string) string {
 		CustomerID    string              `json:"customer_id"`
 		CustomerName  string              `json:"customer_name"`
 		CustomerPhone string              `json:"customer_phone"`
+		Currency      string              `json:"currency"`
+		IncludeTax    *bool               `json:"prices_include_tax"`
+		DiscountType  *string             `json:"discount_type"`
+		DiscountValue *float64            `json:"discount_value"`
+		VehicleID     *string             `json:"vehicle_id"`
+		MileageIn     *int                `json:"mileage_in"`
+		MileageOut    *int                `json:"mileage_out"`
+		Complaint     *string             `json:"complaint"`
+		WorkPerformed *string             `json:"work_performed"`
+		Status        string              `json:"status"` // New orders: "draft" (default) or "confirmed"
 		Items         []storage.OrderItem `json:"items"`
 	}
 
@@ -254,6 +396,33 @@ func saveOrderToBleve(orderJSON string) string {
 	order.CustomerID = customerID
 	order.CustomerName = orderData.CustomerName
 	order.Items = orderData.Items
+	if orderData.Currency != "" {
+		order.Currency = orderData.Currency
+	}
+	if orderData.IncludeTax != nil {
+		order.PricesIncludeTax = *orderData.IncludeTax
+	}
+	if orderData.DiscountType != nil {
+		order.DiscountType = *orderData.DiscountType
+	}
+	if orderData.DiscountValue != nil {
+		order.DiscountValue = *orderData.DiscountValue
+	}
+	if orderData.VehicleID != nil {
+		order.VehicleID = *orderData.VehicleID
+	}
+	if orderData.MileageIn != nil {
+		order.MileageIn = *orderData.MileageIn
+	}
+	if orderData.MileageOut != nil {
+		order.MileageOut = *orderData.MileageOut
+	}
+	if orderData.Complaint != nil {
+		order.Complaint = *orderData.Complaint
+	}
+	if orderData.WorkPerformed != nil {
+		order.WorkPerformed = *orderData.WorkPerformed
+	}
 	order.CalculateGrandTotal()
 
 	// Save or update order
@@ -263,17 +432,18 @@ func saveOrderToBleve(orderJSON string) string {
 			return jsonError(err)
 		}
 	} else {
+		order.Status = orderData.Status // Existing orders change status with setOrderStatus / deliverOrder
 		if err := store.SaveOrder(order); err != nil {
 			return jsonError(err)
 		}
 	}
 
-	// Update customer statistics
-	if customerID != "" {
-		store.UpdateCustomerStats(customerID)
+	// Update customer statistics (the customer may come from the order's vehicle)
+	if order.CustomerID != "" {
+		store.UpdateCustomerStats(order.CustomerID)
 	}
 
-	return fmt.Sprintf(`{"success": true, "id": "%s", "customer_id": "%s"}`, order.ID, customerID)
+	return fmt.Sprintf(`{"success": true, "id": "%s", "customer_id": "%s"}`, order.ID, order.CustomerID)
 }
 
 // loadOrdersFromBleve loads orders with optional filtering
@@ -337,6 +507,51 @@ func deleteOrderFromBleve(id string) string {
 	return jsonSuccess()
 }
 
+// setOrderStatus moves an order between draft and confirmed (confirmed reserves stock)
+func setOrderStatus(dataJSON string) string {
+	var data struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	if err := store.SetOrderStatus(data.ID, data.Status); err != nil {
+		return jsonError(err)
+	}
+
+	return jsonSuccess()
+}
+
+// deliverOrder delivers an order and takes its products out of stock
+func deliverOrder(dataJSON string) string {
+	var data struct {
+		ID         string `json:"id"`
+		LocationID string `json:"location_id"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	if err := store.DeliverOrder(data.ID, data.LocationID); err != nil {
+		return jsonError(err)
+	}
+
+	return jsonSuccess()
+}
+
+// getProductReservations returns the confirmed orders holding stock of a product
+func getProductReservations(productID string) string {
+	reservations, err := store.GetProductReservations(productID)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(reservations)
+}
+
 // searchOrders searches orders by term
 func searchOrders(searchTerm string) string {
 	orders, err := store.SearchOrders(searchTerm)
@@ -352,8 +567,8 @@ func searchOrdersAdvanced(filterJSON string) string {
 		ProductName  string  `json:"product_name"`
 		OEMNumber    string  `json:"oem_number"`
 		CustomerName string  `json:"customer_name"`
-		MinQuantity  int     `json:"min_quantity"`
-		MaxQuantity  int     `json:"max_quantity"`
+		MinQuantity  float64 `json:"min_quantity"`
+		MaxQuantity  float64 `json:"max_quantity"`
 		MinTotal     float64 `json:"min_total"`
 		MaxTotal     float64 `json:"max_total"`
 		MinUnitPrice float64 `json:"min_unit_price"`
@@ -388,6 +603,70 @@ func searchOrdersAdvanced(filterJSON string) string {
 	return jsonMarshal(orders)
 }
 
+// getSalesReport generates a sales report (discounts and tax) for a given period
+func getSalesReport(filterJSON string) string {
+	var filter struct {
+		Period  string `json:"period"` // "daily" or "monthly"
+		DateStr string `json:"date"`   // "2024-01-15" or "2024-01"
+	}
+
+	if err := json.Unmarshal([]byte(filterJSON), &filter); err != nil {
+		return jsonError(err)
+	}
+
+	layout := "2006-01"
+	if filter.Period == "daily" {
+		layout = "2006-01-02"
+	}
+
+	date := time.Now()
+	if filter.DateStr != "" {
+		var err error
+		if date, err = time.ParseInLocation(layout, filter.DateStr, time.Local); err != nil {
+			return jsonError(fmt.Errorf("geçersiz tarih: %q", filter.DateStr))
+		}
+	}
+
+	report, err := store.GetSalesReport(filter.Period, date)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(report)
+}
+
+// getProfitabilityReport returns margin and profit per order, customer and product
+func getProfitabilityReport(filterJSON string) string {
+	var filter struct {
+		StartDate string `json:"start_date"`
+		EndDate   string `json:"end_date"`
+	}
+
+	if err := json.Unmarshal([]byte(filterJSON), &filter); err != nil {
+		return jsonError(err)
+	}
+
+	startDate, endDate := time.Now().AddDate(0, -1, 0), time.Now() // Last month
+	var err error
+	if filter.StartDate != "" {
+		if startDate, err = time.ParseInLocation("2006-01-02", filter.StartDate, time.Local); err != nil {
+			return jsonError(fmt.Errorf("geçersiz başlangıç tarihi: %q", filter.StartDate))
+		}
+	}
+	if filter.EndDate != "" {
+		if endDate, err = time.ParseInLocation("2006-01-02", filter.EndDate, time.Local); err != nil {
+			return jsonError(fmt.Errorf("geçersiz bitiş tarihi: %q", filter.EndDate))
+		}
+	}
+
+	report, err := store.GetProfitabilityReport(startDate, endDate)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(report)
+}
+
 // =============================================================================
 // Customer Functions
 // =============================================================================
@@ -619,23 +898,145 @@ func getUnits() string {
 	return jsonMarshal(units)
 }
 
+// updateProductPurchasePrice updates a product's supplier price and currency
+func updateProductPurchasePrice(dataJSON string) string {
+	var data struct {
+		ID       string        `json:"id"`
+		Price    storage.Money `json:"purchase_price"`
+		Currency string        `json:"purchase_currency"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	if err := store.UpdateProductPurchasePrice(data.ID, data.Price, data.Currency); err != nil {
+		return jsonError(err)
+	}
+
+	return jsonSuccess()
+}
+
+// setProductLotTracking turns lot and expiry tracking on or off for a product
+func setProductLotTracking(dataJSON string) string {
+	var data struct {
+		ID      string `json:"id"`
+		Enabled bool   `json:"enabled"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	if err := store.SetProductLotTracking(data.ID, data.Enabled); err != nil {
+		return jsonError(err)
+	}
+
+	return jsonSuccess()
+}
+
+// setProductSerialTracking turns serial number tracking on or off for a product
+func setProductSerialTracking(dataJSON string) string {
+	var data struct {
+		ID      string `json:"id"`
+		Enabled bool   `json:"enabled"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	if err := store.SetProductSerialTracking(data.ID, data.Enabled); err != nil {
+		return jsonError(err)
+	}
+
+	return jsonSuccess()
+}
+
+// setProductUnits sets a product's unit conversions and default purchase / sale units
+func setProductUnits(dataJSON string) string {
+	var data struct {
+		ID           string                   `json:"id"`
+		Conversions  []storage.UnitConversion `json:"conversions"`
+		PurchaseUnit string                   `json:"purchase_unit"`
+		SaleUnit     string                   `json:"sale_unit"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	if err := store.SetProductUnits(data.ID, data.Conversions, data.PurchaseUnit, data.SaleUnit); err != nil {
+		return jsonError(err)
+	}
+
+	return jsonSuccess()
+}
+
+// findSerialNumber returns a serial number's records with their full history
+func findSerialNumber(serial string) string {
+	records, err := store.FindSerialNumbers(serial)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(records)
+}
+
+// listProductSerials lists a product's serial numbers, optionally by status
+func listProductSerials(filterJSON string) string {
+	var filter struct {
+		ProductID string `json:"product_id"`
+		Status    string `json:"status"` // "in_stock", "out", "returned" or empty for all
+	}
+
+	if err := json.Unmarshal([]byte(filterJSON), &filter); err != nil {
+		return jsonError(err)
+	}
+
+	records, err := store.ListProductSerials(filter.ProductID, filter.Status)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(records)
+}
+
+// returnSerial records a customer return of a serial-tracked unit
+func returnSerial(dataJSON string) string {
+	var data struct {
+		ProductID  string `json:"product_id"`
+		Serial     string `json:"serial"`
+		LocationID string `json:"location_id"`
+		Restock    bool   `json:"restock"` // Put the unit back into stock
+		Note       string `json:"note"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	record, err := store.ReturnSerial(data.ProductID, data.Serial, data.LocationID, data.Restock, data.Note)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(record)
+}
+
 // =============================================================================
 // Stock Management Functions
 // =============================================================================
 
-// stockIn adds stock to a product
+// stockIn adds stock to a product, optionally with a purchase cost
 func stockIn(dataJSON string) string {
-	var data struct {
-		ProductID string  `json:"product_id"`
-		Amount    float64 `json:"amount"`
-		Note      string  `json:"note"`
-	}
+	var data storage.BulkStockInfo
 
 	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
 		return jsonError(err)
 	}
 
-	if err := store.StockIn(data.ProductID, data.Amount, data.Note); err != nil {
+	if err := store.StockInEntry(data); err != nil {
 		return jsonError(err)
 	}
 
@@ -644,21 +1045,39 @@ func stockIn(dataJSON string) string {
 
 // stockOut removes stock from a product
 func stockOut(dataJSON string) string {
+	var data storage.BulkStockInfo
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	if err := store.StockOutEntry(data); err != nil {
+		return jsonError(err)
+	}
+
+	return jsonSuccess()
+}
+
+// adjustStock changes stock by a signed amount with a reason
+func adjustStock(dataJSON string) string {
 	var data struct {
-		ProductID string  `json:"product_id"`
-		Amount    float64 `json:"amount"`
-		Note      string  `json:"note"`
+		ProductID  string  `json:"product_id"`
+		LocationID string  `json:"location_id"` // Empty = default location
+		Amount     float64 `json:"amount"`      // + increase, - decrease
+		Reason     string  `json:"reason"`
+		Note       string  `json:"note"`
 	}
 
 	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
 		return jsonError(err)
 	}
 
-	if err := store.StockOut(data.ProductID, data.Amount, data.Note); err != nil {
+	movement, err := store.AdjustStock(data.ProductID, data.LocationID, data.Amount, data.Reason, data.Note)
+	if err != nil {
 		return jsonError(err)
 	}
 
-	return jsonSuccess()
+	return jsonMarshal(movement)
 }
 
 // bulkStockIn adds stock to multiple products
@@ -699,6 +1118,25 @@ func bulkStockOut(dataJSON string) string {
 	return jsonMarshal(result)
 }
 
+// reverseStockMovement reverses a stock movement with a linked counter-movement
+func reverseStockMovement(dataJSON string) string {
+	var data struct {
+		MovementID string `json:"movement_id"`
+		Note       string `json:"note"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	counter, err := store.ReverseStockMovement(data.MovementID, data.Note)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(counter)
+}
+
 // getStockMovements returns stock movement history
 func getStockMovements(filterJSON string) string {
 	var filter struct {
@@ -730,7 +1168,7 @@ func getStockMovements(filterJSON string) string {
 
 // getCriticalStockProducts returns products below critical stock level
 func getCriticalStockProducts() string {
-	products, err := store.GetCriticalStockProducts()
+	products, err := store.GetCriticalStockProducts("")
 	if err != nil {
 		return jsonError(err)
 	}
@@ -767,43 +1205,313 @@ func getStockReport(filterJSON string) string {
 	return jsonMarshal(report)
 }
 
-// =============================================================================
-// Helper Functions
-// =============================================================================
+// startStockCount opens a new stock-take session
+func startStockCount(dataJSON string) string {
+	var data struct {
+		Title      string `json:"title"`
+		LocationID string `json:"location_id"` // Empty = default location
+		Note       string `json:"note"`
+	}
 
-// showErrorDialog displays a Windows error dialog
-func showErrorDialog(title, message string) {
-	user32 := syscall.NewLazyDLL("user32.dll")
-	messageBox := user32.NewProc("MessageBoxW")
+	if dataJSON != "" {
+		if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+			return jsonError(err)
+		}
+	}
 
-	titlePtr, _ := syscall.UTF16PtrFromString(title)
-	messagePtr, _ := syscall.UTF16PtrFromString(message)
+	count, err := store.StartStockCount(data.Title, data.LocationID, data.Note)
+	if err != nil {
+		return jsonError(err)
+	}
 
-	// MB_OK | MB_ICONERROR = 0x10
-	messageBox.Call(0, uintptr(unsafe.Pointer(messagePtr)), uintptr(unsafe.Pointer(titlePtr)), 0x10)
+	return jsonMarshal(count)
 }
 
-// jsonError returns a JSON error response
-func jsonError(err error) string {
-	return fmt.Sprintf(`{"error": "%s"}`, err.Error())
-}
+// listStockCounts lists stock-take sessions
+func listStockCounts() string {
+	counts, err := store.ListStockCounts()
+	if err != nil {
+		return jsonError(err)
+	}
 
-// jsonSuccess returns a JSON success response
-func jsonSuccess() string {
-	return `{"success": true}`
+	return jsonMarshal(counts)
 }
 
-// jsonMarshal marshals data to JSON string
-func jsonMarshal(data interface{}) string {
-	bytes, _ := json.Marshal(data)
-	return string(bytes)
+// getStockCount returns a stock-take session with its differences
+func getStockCount(id string) string {
+	count, err := store.GetStockCount(id)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(count)
 }
 
-// =============================================================================
-// Settings Functions
-// =============================================================================
+// setStockCountLine enters the counted quantity of a product
+func setStockCountLine(dataJSON string) string {
+	var data struct {
+		CountID         string  `json:"count_id"`
+		ProductID       string  `json:"product_id"`
+		CountedQuantity float64 `json:"counted_quantity"`
+		Note            string  `json:"note"`
+	}
 
-// setDeveloperMode enables or disables developer mode
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	count, err := store.SetStockCountLine(data.CountID, data.ProductID, data.CountedQuantity, data.Note)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(count)
+}
+
+// removeStockCountLine removes a product from a stock-take session
+func removeStockCountLine(dataJSON string) string {
+	var data struct {
+		CountID   string `json:"count_id"`
+		ProductID string `json:"product_id"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	count, err := store.RemoveStockCountLine(data.CountID, data.ProductID)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(count)
+}
+
+// commitStockCount posts adjustments for the differences and closes the session
+func commitStockCount(id string) string {
+	count, err := store.CommitStockCount(id)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(count)
+}
+
+// cancelStockCount closes a session without changing stock
+func cancelStockCount(id string) string {
+	count, err := store.CancelStockCount(id)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(count)
+}
+
+// getExpiryReport returns expired and soon-to-expire lots
+func getExpiryReport(filterJSON string) string {
+	var filter struct {
+		WithinDays int `json:"within_days"` // Default 30
+	}
+
+	if filterJSON != "" {
+		if err := json.Unmarshal([]byte(filterJSON), &filter); err != nil {
+			return jsonError(err)
+		}
+	}
+
+	report, err := store.GetExpiryReport(filter.WithinDays)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(report)
+}
+
+// getDemandForecast forecasts weekly consumption and flags products likely to run out
+func getDemandForecast(optionsJSON string) string {
+	var options storage.ForecastOptions
+
+	if optionsJSON != "" {
+		if err := json.Unmarshal([]byte(optionsJSON), &options); err != nil {
+			return jsonError(err)
+		}
+	}
+
+	forecast, err := store.GetDemandForecast(options)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(forecast)
+}
+
+// getStockLevelsAt returns each product's stock level at a date, rebuilt from movements
+func getStockLevelsAt(filterJSON string) string {
+	var filter struct {
+		DateStr string `json:"date"` // "2024-01-15" or "2024-01-15T14:30", empty = now
+	}
+
+	if err := json.Unmarshal([]byte(filterJSON), &filter); err != nil {
+		return jsonError(err)
+	}
+
+	at := time.Now()
+	if filter.DateStr != "" {
+		if t, err := time.ParseInLocation("2006-01-02T15:04", filter.DateStr, time.Local); err == nil {
+			at = t
+		} else {
+			date, err := time.ParseInLocation("2006-01-02", filter.DateStr, time.Local)
+			if err != nil {
+				return jsonError(err)
+			}
+			at = date.Add(24*time.Hour - time.Nanosecond) // End of day
+		}
+	}
+
+	levels, err := store.GetStockLevelsAt(at)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(levels)
+}
+
+// reconcileStock compares stored stock with the movement ledger
+// With apply=true, correcting adjustment movements are posted for mismatches.
+func reconcileStock(optionsJSON string) string {
+	var options struct {
+		Apply bool `json:"apply"`
+	}
+
+	if optionsJSON != "" {
+		if err := json.Unmarshal([]byte(optionsJSON), &options); err != nil {
+			return jsonError(err)
+		}
+	}
+
+	result, err := store.ReconcileStock(options.Apply)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(result)
+}
+
+// getInventoryValuation returns stock quantity and value per product at a date
+func getInventoryValuation(filterJSON string) string {
+	var filter struct {
+		DateStr string `json:"date"`   // "2024-01-15", empty = now
+		Method  string `json:"method"` // "fifo", "average" or empty for the configured method
+	}
+
+	if err := json.Unmarshal([]byte(filterJSON), &filter); err != nil {
+		return jsonError(err)
+	}
+
+	at := time.Now()
+	if filter.DateStr != "" {
+		date, err := time.ParseInLocation("2006-01-02", filter.DateStr, time.Local)
+		if err != nil {
+			return jsonError(err)
+		}
+		at = date.Add(24*time.Hour - time.Nanosecond) // End of day
+	}
+
+	valuation, err := store.GetInventoryValuation(at, filter.Method)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(valuation)
+}
+
+// parseDateRange parses optional "2006-01-02" start and end dates in local time (empty = zero time)
+func parseDateRange(startStr, endStr string) (time.Time, time.Time, error) {
+	var start, end time.Time
+	var err error
+	if startStr != "" {
+		if start, err = time.ParseInLocation("2006-01-02", startStr, time.Local); err != nil {
+			return start, end, fmt.Errorf("geçersiz başlangıç tarihi: %q", startStr)
+		}
+	}
+	if endStr != "" {
+		if end, err = time.ParseInLocation("2006-01-02", endStr, time.Local); err != nil {
+			return start, end, fmt.Errorf("geçersiz bitiş tarihi: %q", endStr)
+		}
+	}
+	return start, end, nil
+}
+
+// getCostOfGoodsSold returns the cost of stock-outs for a period
+func getCostOfGoodsSold(filterJSON string) string {
+	var filter struct {
+		StartDate string `json:"start_date"`
+		EndDate   string `json:"end_date"`
+		Method    string `json:"method"`
+	}
+
+	if err := json.Unmarshal([]byte(filterJSON), &filter); err != nil {
+		return jsonError(err)
+	}
+
+	start, end, err := parseDateRange(filter.StartDate, filter.EndDate)
+	if err != nil {
+		return jsonError(err)
+	}
+	if start.IsZero() {
+		now := time.Now()
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local) // This month
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+	end = time.Date(end.Year(), end.Month(), end.Day(), 23, 59, 59, 0, time.Local)
+
+	cogs, err := store.GetCostOfGoodsSold(start, end, filter.Method)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(cogs)
+}
+
+// =============================================================================
+// Helper Functions
+// =============================================================================
+
+// showErrorDialog displays a Windows error dialog
+func showErrorDialog(title, message string) {
+	user32 := syscall.NewLazyDLL("user32.dll")
+	messageBox := user32.NewProc("MessageBoxW")
+
+	titlePtr, _ := syscall.UTF16PtrFromString(title)
+	messagePtr, _ := syscall.UTF16PtrFromString(message)
+
+	// MB_OK | MB_ICONERROR = 0x10
+	messageBox.Call(0, uintptr(unsafe.Pointer(messagePtr)), uintptr(unsafe.Pointer(titlePtr)), 0x10)
+}
+
+// jsonError returns a JSON error response
+func jsonError(err error) string {
+	return fmt.Sprintf(`{"error": "%s"}`, err.Error())
+}
+
+// jsonSuccess returns a JSON success response
+func jsonSuccess() string {
+	return `{"success": true}`
+}
+
+// jsonMarshal marshals data to JSON string
+func jsonMarshal(data interface{}) string {
+	bytes, _ := json.Marshal(data)
+	return string(bytes)
+}
+
+// =============================================================================
+// Settings Functions
+// =============================================================================
+
+// setDeveloperMode enables or disables developer mode
 // Note: Changes take effect after application restart
 func setDeveloperMode(enabled bool) string {
 	if err := storage.UpdateDeveloperMode(enabled); err != nil {
@@ -821,3 +1529,1159 @@ func getDeveloperMode() string {
 	enabled := storage.IsDeveloperMode()
 	return jsonMarshal(map[string]bool{"enabled": enabled})
 }
+
+// getValuationMethod returns the configured inventory valuation method
+func getValuationMethod() string {
+	return jsonMarshal(map[string]string{"method": storage.GetValuationMethod()})
+}
+
+// setValuationMethod sets the inventory valuation method ("fifo" or "average")
+func setValuationMethod(method string) string {
+	if err := storage.UpdateValuationMethod(method); err != nil {
+		return jsonError(err)
+	}
+	return jsonSuccess()
+}
+
+// getTaxRates returns KDV rates per product category
+func getTaxRates() string {
+	return jsonMarshal(map[string]interface{}{
+		"default":    storage.DefaultTaxRate,
+		"categories": storage.GetTaxRates(),
+	})
+}
+
+// setCategoryTaxRate updates the KDV rate of a product category
+func setCategoryTaxRate(dataJSON string) string {
+	var data struct {
+		Category string  `json:"category"`
+		Rate     float64 `json:"rate"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	if err := storage.UpdateCategoryTaxRate(data.Category, data.Rate); err != nil {
+		return jsonError(err)
+	}
+
+	return jsonSuccess()
+}
+
+// =============================================================================
+// Location Functions
+// =============================================================================
+
+// listLocations returns all stock locations
+func listLocations() string {
+	locations, err := store.ListLocations()
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(locations)
+}
+
+// createLocation creates a new stock location
+func createLocation(dataJSON string) string {
+	var data struct {
+		Name string `json:"name"`
+		Note string `json:"note"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	location, err := store.CreateLocation(data.Name, data.Note)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(location)
+}
+
+// updateLocation renames a stock location
+func updateLocation(dataJSON string) string {
+	var data struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Note string `json:"note"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	if err := store.UpdateLocation(data.ID, data.Name, data.Note); err != nil {
+		return jsonError(err)
+	}
+
+	return jsonSuccess()
+}
+
+// deleteLocation deletes an empty stock location
+func deleteLocation(id string) string {
+	if err := store.DeleteLocation(id); err != nil {
+		return jsonError(err)
+	}
+
+	return jsonSuccess()
+}
+
+// transferStock moves stock between two locations
+func transferStock(dataJSON string) string {
+	var data struct {
+		ProductID      string   `json:"product_id"`
+		FromLocationID string   `json:"from_location_id"`
+		ToLocationID   string   `json:"to_location_id"`
+		Amount         float64  `json:"amount"`
+		SerialNumbers  []string `json:"serial_numbers"`
+		Note           string   `json:"note"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	movement, err := store.TransferStock(data.ProductID, data.FromLocationID, data.ToLocationID, data.Amount, data.SerialNumbers, data.Note)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(movement)
+}
+
+// getProductLocationStock returns a product's stock at every location
+func getProductLocationStock(productID string) string {
+	levels, err := store.GetProductLocationStock(productID)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(levels)
+}
+
+// setLocationCriticalStock sets a product's critical level at a location
+func setLocationCriticalStock(dataJSON string) string {
+	var data struct {
+		ProductID     string `json:"product_id"`
+		LocationID    string `json:"location_id"`
+		CriticalStock int    `json:"critical_stock"` // 0 = use product level
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	if err := store.SetLocationCriticalStock(data.ProductID, data.LocationID, data.CriticalStock); err != nil {
+		return jsonError(err)
+	}
+
+	return jsonSuccess()
+}
+
+// getCriticalStockAtLocation returns products below critical level at one location
+func getCriticalStockAtLocation(locationID string) string {
+	if locationID == "" {
+		locationID = storage.DefaultLocationID
+	}
+
+	products, err := store.GetCriticalStockProducts(locationID)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(products)
+}
+
+// getCriticalStockByLocation returns critical products for every location
+func getCriticalStockByLocation() string {
+	items, err := store.GetCriticalStockByLocation()
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(items)
+}
+
+// =============================================================================
+// Purchase Functions
+// =============================================================================
+
+// setCustomerSupplier marks a party as supplier or removes the role
+func setCustomerSupplier(dataJSON string) string {
+	var data struct {
+		ID         string `json:"id"`
+		IsSupplier bool   `json:"is_supplier"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	if err := store.SetCustomerSupplier(data.ID, data.IsSupplier); err != nil {
+		return jsonError(err)
+	}
+
+	return jsonSuccess()
+}
+
+// listSuppliers returns parties with the supplier role
+func listSuppliers() string {
+	suppliers, err := store.ListSuppliers()
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(suppliers)
+}
+
+// purchaseOrderInput is the editable part of a purchase order sent by the UI
+type purchaseOrderInput struct {
+	ID           string                      `json:"id"`
+	SupplierID   string                      `json:"supplier_id"`
+	Currency     string                      `json:"currency"`
+	Items        []storage.PurchaseOrderItem `json:"items"`
+	Note         string                      `json:"note"`
+	OrderDate    string                      `json:"order_date"`    // "2006-01-02" (empty = today)
+	ExpectedDate string                      `json:"expected_date"` // "2006-01-02" (optional)
+}
+
+// toPurchaseOrder converts the UI input to a purchase order
+func (in purchaseOrderInput) toPurchaseOrder() (*storage.PurchaseOrder, error) {
+	po := &storage.PurchaseOrder{
+		ID:         in.ID,
+		SupplierID: in.SupplierID,
+		Currency:   in.Currency,
+		Items:      in.Items,
+		Note:       in.Note,
+	}
+
+	if in.OrderDate != "" {
+		date, err := time.ParseInLocation("2006-01-02", in.OrderDate, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("geçersiz sipariş tarihi: %q", in.OrderDate)
+		}
+		po.OrderDate = date
+	}
+	if in.ExpectedDate != "" {
+		date, err := time.ParseInLocation("2006-01-02", in.ExpectedDate, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("geçersiz teslim tarihi: %q", in.ExpectedDate)
+		}
+		po.ExpectedDate = &date
+	}
+
+	return po, nil
+}
+
+// createPurchaseOrder creates a purchase order for a supplier
+func createPurchaseOrder(dataJSON string) string {
+	var data purchaseOrderInput
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	po, err := data.toPurchaseOrder()
+	if err != nil {
+		return jsonError(err)
+	}
+
+	po, err = store.CreatePurchaseOrder(po)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(po)
+}
+
+// updatePurchaseOrder updates an open purchase order
+func updatePurchaseOrder(dataJSON string) string {
+	var data purchaseOrderInput
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	po, err := data.toPurchaseOrder()
+	if err != nil {
+		return jsonError(err)
+	}
+
+	po, err = store.UpdatePurchaseOrder(po)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(po)
+}
+
+// getPurchaseOrder returns a purchase order with its goods receipts
+func getPurchaseOrder(id string) string {
+	po, err := store.GetPurchaseOrder(id)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(po)
+}
+
+// listPurchaseOrders lists purchase orders, optionally by supplier and status
+func listPurchaseOrders(dataJSON string) string {
+	var data struct {
+		SupplierID string `json:"supplier_id"`
+		Status     string `json:"status"`
+	}
+
+	if dataJSON != "" {
+		if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+			return jsonError(err)
+		}
+	}
+
+	orders, err := store.ListPurchaseOrders(data.SupplierID, data.Status)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(orders)
+}
+
+// closePurchaseOrder closes a purchase order without waiting for the rest
+func closePurchaseOrder(dataJSON string) string {
+	var data struct {
+		ID   string `json:"id"`
+		Note string `json:"note"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	po, err := store.ClosePurchaseOrder(data.ID, data.Note)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(po)
+}
+
+// deletePurchaseOrder deletes a purchase order without goods receipts
+func deletePurchaseOrder(id string) string {
+	if err := store.DeletePurchaseOrder(id); err != nil {
+		return jsonError(err)
+	}
+	return jsonSuccess()
+}
+
+// receiveGoods posts a goods receipt against a purchase order
+func receiveGoods(dataJSON string) string {
+	var data struct {
+		PurchaseOrderID string               `json:"purchase_order_id"`
+		Receipt         storage.GoodsReceipt `json:"receipt"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	po, err := store.ReceiveGoods(data.PurchaseOrderID, data.Receipt)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(po)
+}
+
+// setProductReorder sets a product's reorder point, target, preferred supplier and lead time
+func setProductReorder(dataJSON string) string {
+	var data struct {
+		ID string `json:"id"`
+		storage.ReorderSettings
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	if err := store.SetProductReorder(data.ID, data.ReorderSettings); err != nil {
+		return jsonError(err)
+	}
+
+	return jsonSuccess()
+}
+
+// reorderFilter selects the consumption period of the reorder plan
+type reorderFilter struct {
+	UsageDays int `json:"usage_days"` // Default 90
+}
+
+// parseReorderFilter decodes an optional reorder filter
+func parseReorderFilter(filterJSON string) (reorderFilter, error) {
+	var filter reorderFilter
+	if filterJSON != "" {
+		if err := json.Unmarshal([]byte(filterJSON), &filter); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+// getReorderPlan returns suggested purchases grouped by supplier
+func getReorderPlan(filterJSON string) string {
+	filter, err := parseReorderFilter(filterJSON)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	plan, err := store.GetReorderPlan(filter.UsageDays)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(plan)
+}
+
+// createReorderPurchaseOrders turns the reorder plan into purchase orders
+func createReorderPurchaseOrders(dataJSON string) string {
+	var data struct {
+		SupplierIDs []string `json:"supplier_ids"`
+		UsageDays   int      `json:"usage_days"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	orders, err := store.CreateReorderPurchaseOrders(data.SupplierIDs, data.UsageDays)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(orders)
+}
+
+// exportReorderPlan returns the reorder plan as CSV content
+func exportReorderPlan(filterJSON string) string {
+	filter, err := parseReorderFilter(filterJSON)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	content, err := store.ExportReorderPlanCSV(filter.UsageDays)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(map[string]interface{}{
+		"success":  true,
+		"filename": fmt.Sprintf("siparis-onerisi-%s.csv", time.Now().Format("2006-01-02")),
+		"content":  content,
+	})
+}
+
+// =============================================================================
+// Receivable Functions
+// =============================================================================
+
+// paymentInput is a payment as sent by the UI
+type paymentInput struct {
+	ID          string                      `json:"id"`
+	CustomerID  string                      `json:"customer_id"`
+	Date        string                      `json:"date"` // "2006-01-02" (empty = now)
+	Method      string                      `json:"method"`
+	Amount      storage.Money               `json:"amount"`
+	Currency    string                      `json:"currency"`
+	Note        string                      `json:"note"`
+	Allocations []storage.PaymentAllocation `json:"allocations"` // Order allocations (base currency)
+}
+
+// toPayment converts the UI input to a payment
+func (in paymentInput) toPayment() (*storage.Payment, error) {
+	payment := &storage.Payment{
+		ID:          in.ID,
+		CustomerID:  in.CustomerID,
+		Method:      in.Method,
+		Amount:      in.Amount,
+		Currency:    in.Currency,
+		Note:        in.Note,
+		Allocations: in.Allocations,
+	}
+
+	if in.Date != "" && in.Date != time.Now().Format("2006-01-02") {
+		date, err := time.ParseInLocation("2006-01-02", in.Date, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("geçersiz ödeme tarihi: %q", in.Date)
+		}
+		payment.Date = date
+	}
+
+	return payment, nil
+}
+
+// createPayment records a payment received from a customer
+func createPayment(dataJSON string) string {
+	var data paymentInput
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	payment, err := data.toPayment()
+	if err != nil {
+		return jsonError(err)
+	}
+	payment, err = store.CreatePayment(payment)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(payment)
+}
+
+// updatePayment updates a customer payment
+func updatePayment(dataJSON string) string {
+	var data paymentInput
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	payment, err := data.toPayment()
+	if err != nil {
+		return jsonError(err)
+	}
+	payment, err = store.UpdatePayment(payment)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(payment)
+}
+
+// deletePayment removes a customer payment
+func deletePayment(id string) string {
+	if err := store.DeletePayment(id); err != nil {
+		return jsonError(err)
+	}
+	return jsonSuccess()
+}
+
+// statementFilter selects a customer and an optional period
+type statementFilter struct {
+	CustomerID string `json:"customer_id"`
+	StartStr   string `json:"start"` // "2006-01-02" (optional)
+	EndStr     string `json:"end"`   // "2006-01-02" (optional, inclusive)
+}
+
+// parseStatementFilter parses the filter JSON and its dates
+func parseStatementFilter(filterJSON string) (statementFilter, time.Time, time.Time, error) {
+	var filter statementFilter
+	var start, end time.Time
+	if err := json.Unmarshal([]byte(filterJSON), &filter); err != nil {
+		return filter, start, end, err
+	}
+
+	start, end, err := parseDateRange(filter.StartStr, filter.EndStr)
+	if err != nil {
+		return filter, start, end, err
+	}
+	if !end.IsZero() {
+		end = end.Add(24*time.Hour - time.Nanosecond) // Include end of day
+	}
+
+	return filter, start, end, nil
+}
+
+// listPayments returns payments, optionally for one customer and period
+func listPayments(filterJSON string) string {
+	filter, start, end, err := parseStatementFilter(filterJSON)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	payments, err := store.ListPayments(filter.CustomerID, start, end)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(payments)
+}
+
+// getOrderPayments returns the payments allocated to an order
+func getOrderPayments(orderID string) string {
+	payments, err := store.GetOrderPayments(orderID)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(payments)
+}
+
+// getCustomerLedger returns a customer's full ledger with running balance
+func getCustomerLedger(customerID string) string {
+	ledger, err := store.GetCustomerLedger(customerID)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(ledger)
+}
+
+// getCustomerStatement returns a customer statement for a period
+func getCustomerStatement(filterJSON string) string {
+	filter, start, end, err := parseStatementFilter(filterJSON)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	statement, err := store.GetCustomerStatement(filter.CustomerID, start, end)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(statement)
+}
+
+// getAgedReceivables returns open customer balances by age
+func getAgedReceivables(dateStr string) string {
+	var date time.Time
+	if dateStr != "" {
+		var err error
+		if date, err = time.ParseInLocation("2006-01-02", dateStr, time.Local); err != nil {
+			return jsonError(fmt.Errorf("geçersiz tarih: %q", dateStr))
+		}
+		date = date.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	report, err := store.GetAgedReceivables(date)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(report)
+}
+
+// =============================================================================
+// Cash Book Functions
+// =============================================================================
+
+// parseCashDate parses a cash day ("2006-01-02", empty = today)
+func parseCashDate(dateStr string) (time.Time, error) {
+	if dateStr == "" {
+		return time.Now(), nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", dateStr, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("geçersiz tarih: %q", dateStr)
+	}
+	return date, nil
+}
+
+// getCashCategories returns the suggested cash-out categories
+func getCashCategories() string {
+	return jsonMarshal(storage.CashCategories)
+}
+
+// cashEntryInput is a cash entry as sent by the UI
+type cashEntryInput struct {
+	ID          string        `json:"id"`
+	Date        string        `json:"date"` // "2006-01-02" (empty = now)
+	Type        string        `json:"type"` // "in" or "out"
+	Category    string        `json:"category"`
+	Amount      storage.Money `json:"amount"`
+	Description string        `json:"description"`
+}
+
+// toCashEntry converts the UI input to a cash entry
+func (in cashEntryInput) toCashEntry() (*storage.CashEntry, error) {
+	entry := &storage.CashEntry{
+		ID:          in.ID,
+		Type:        in.Type,
+		Category:    in.Category,
+		Amount:      in.Amount,
+		Description: in.Description,
+	}
+
+	if in.Date != "" && in.Date != time.Now().Format("2006-01-02") {
+		date, err := parseCashDate(in.Date)
+		if err != nil {
+			return nil, err
+		}
+		entry.Date = date
+	}
+
+	return entry, nil
+}
+
+// createCashEntry records a manual cash in or out
+func createCashEntry(dataJSON string) string {
+	var data cashEntryInput
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	entry, err := data.toCashEntry()
+	if err != nil {
+		return jsonError(err)
+	}
+	entry, err = store.CreateCashEntry(entry)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(entry)
+}
+
+// updateCashEntry updates a manual cash entry
+func updateCashEntry(dataJSON string) string {
+	var data cashEntryInput
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	entry, err := data.toCashEntry()
+	if err != nil {
+		return jsonError(err)
+	}
+	entry, err = store.UpdateCashEntry(entry)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(entry)
+}
+
+// deleteCashEntry removes a manual cash entry
+func deleteCashEntry(id string) string {
+	if err := store.DeleteCashEntry(id); err != nil {
+		return jsonError(err)
+	}
+	return jsonSuccess()
+}
+
+// setCashOpeningBalance sets a day's opening balance by hand
+func setCashOpeningBalance(dataJSON string) string {
+	var data struct {
+		Date   string        `json:"date"`
+		Amount storage.Money `json:"amount"`
+	}
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	date, err := parseCashDate(data.Date)
+	if err != nil {
+		return jsonError(err)
+	}
+	if err := store.SetCashOpeningBalance(date, data.Amount); err != nil {
+		return jsonError(err)
+	}
+	return jsonSuccess()
+}
+
+// closeCashDay records the closing count of a day
+func closeCashDay(dataJSON string) string {
+	var data struct {
+		Date    string        `json:"date"`
+		Counted storage.Money `json:"counted"`
+		Note    string        `json:"note"`
+	}
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	date, err := parseCashDate(data.Date)
+	if err != nil {
+		return jsonError(err)
+	}
+	report, err := store.CloseCashDay(date, data.Counted, data.Note)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(report)
+}
+
+// reopenCashDay reopens a closed day
+func reopenCashDay(dateStr string) string {
+	date, err := parseCashDate(dateStr)
+	if err != nil {
+		return jsonError(err)
+	}
+	if err := store.ReopenCashDay(date); err != nil {
+		return jsonError(err)
+	}
+	return jsonSuccess()
+}
+
+// getCashReport returns the daily cash report (Z report)
+func getCashReport(dateStr string) string {
+	date, err := parseCashDate(dateStr)
+	if err != nil {
+		return jsonError(err)
+	}
+	report, err := store.GetCashReport(date)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(report)
+}
+
+// printCashReport returns the daily cash report as printable text
+func printCashReport(dateStr string) string {
+	date, err := parseCashDate(dateStr)
+	if err != nil {
+		return jsonError(err)
+	}
+	report, err := store.GetCashReport(date)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(map[string]interface{}{
+		"success":  true,
+		"filename": fmt.Sprintf("kasa-raporu-%s.txt", report.Date),
+		"content":  storage.FormatCashReport(report),
+	})
+}
+
+// =============================================================================
+// Expense Functions
+// =============================================================================
+
+// getExpenseCategories returns the suggested expense categories
+func getExpenseCategories() string {
+	return jsonMarshal(storage.ExpenseCategories)
+}
+
+// expenseInput is an expense as sent by the UI
+type expenseInput struct {
+	ID          string        `json:"id"`
+	Date        string        `json:"date"` // "2006-01-02" (empty = now)
+	Category    string        `json:"category"`
+	Description string        `json:"description"`
+	Method      string        `json:"method"` // "cash", "card" or "transfer"
+	Amount      storage.Money `json:"amount"`
+	Currency    string        `json:"currency"`
+}
+
+// toExpense converts the UI input to an expense
+func (in expenseInput) toExpense() (*storage.Expense, error) {
+	expense := &storage.Expense{
+		ID:          in.ID,
+		Category:    in.Category,
+		Description: in.Description,
+		Method:      in.Method,
+		Amount:      in.Amount,
+		Currency:    in.Currency,
+	}
+
+	if in.Date != "" && in.Date != time.Now().Format("2006-01-02") {
+		date, err := time.ParseInLocation("2006-01-02", in.Date, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("geçersiz masraf tarihi: %q", in.Date)
+		}
+		expense.Date = date
+	}
+
+	return expense, nil
+}
+
+// createExpense records an expense
+func createExpense(dataJSON string) string {
+	var data expenseInput
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	expense, err := data.toExpense()
+	if err != nil {
+		return jsonError(err)
+	}
+	expense, err = store.CreateExpense(expense)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(expense)
+}
+
+// updateExpense updates an expense
+func updateExpense(dataJSON string) string {
+	var data expenseInput
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	expense, err := data.toExpense()
+	if err != nil {
+		return jsonError(err)
+	}
+	expense, err = store.UpdateExpense(expense)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(expense)
+}
+
+// deleteExpense removes an expense
+func deleteExpense(id string) string {
+	if err := store.DeleteExpense(id); err != nil {
+		return jsonError(err)
+	}
+	return jsonSuccess()
+}
+
+// listExpenses returns expenses, optionally for one category and period
+func listExpenses(filterJSON string) string {
+	var filter struct {
+		Category string `json:"category"`
+		StartStr string `json:"start"`
+		EndStr   string `json:"end"`
+	}
+
+	if err := json.Unmarshal([]byte(filterJSON), &filter); err != nil {
+		return jsonError(err)
+	}
+
+	start, end, err := parseDateRange(filter.StartStr, filter.EndStr)
+	if err != nil {
+		return jsonError(err)
+	}
+	if !end.IsZero() {
+		end = end.Add(24*time.Hour - time.Nanosecond) // Include end of day
+	}
+
+	expenses, err := store.ListExpenses(filter.Category, start, end)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(expenses)
+}
+
+// getProfitLossReport returns a month-by-month profit and loss report
+func getProfitLossReport(filterJSON string) string {
+	var filter struct {
+		StartDate string `json:"start_date"`
+		EndDate   string `json:"end_date"`
+		Method    string `json:"method"`
+	}
+
+	if err := json.Unmarshal([]byte(filterJSON), &filter); err != nil {
+		return jsonError(err)
+	}
+
+	start, end, err := parseDateRange(filter.StartDate, filter.EndDate)
+	if err != nil {
+		return jsonError(err)
+	}
+	if start.IsZero() {
+		now := time.Now()
+		start = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.Local) // This year
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+	end = time.Date(end.Year(), end.Month(), end.Day(), 23, 59, 59, 0, time.Local)
+
+	report, err := store.GetProfitLossReport(start, end, filter.Method)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(report)
+}
+
+// =============================================================================
+// Vehicle Functions
+// =============================================================================
+
+// createVehicle registers a vehicle for a customer
+func createVehicle(vehicleJSON string) string {
+	var vehicle storage.Vehicle
+	if err := json.Unmarshal([]byte(vehicleJSON), &vehicle); err != nil {
+		return jsonError(err)
+	}
+
+	created, err := store.CreateVehicle(&vehicle)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(created)
+}
+
+// updateVehicle updates a vehicle
+func updateVehicle(vehicleJSON string) string {
+	var vehicle storage.Vehicle
+	if err := json.Unmarshal([]byte(vehicleJSON), &vehicle); err != nil {
+		return jsonError(err)
+	}
+
+	updated, err := store.UpdateVehicle(&vehicle)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(updated)
+}
+
+// deleteVehicle removes a vehicle without service history
+func deleteVehicle(id string) string {
+	if err := store.DeleteVehicle(id); err != nil {
+		return jsonError(err)
+	}
+	return jsonSuccess()
+}
+
+// getVehicle returns a vehicle by ID
+func getVehicle(id string) string {
+	vehicle, err := store.GetVehicle(id)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(vehicle)
+}
+
+// listVehicles returns vehicles, optionally of one customer
+func listVehicles(customerID string) string {
+	vehicles, err := store.ListVehicles(customerID)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(vehicles)
+}
+
+// searchVehicles searches vehicles by plate, VIN, make, model or owner
+func searchVehicles(term string) string {
+	vehicles, err := store.SearchVehicles(term)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(vehicles)
+}
+
+// getVehicleHistory returns the orders and stock-outs of a vehicle
+func getVehicleHistory(id string) string {
+	history, err := store.GetVehicleHistory(id)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(history)
+}
+
+// getServiceIntervals returns maintenance intervals per category or service type
+func getServiceIntervals() string {
+	return jsonMarshal(storage.GetServiceIntervals())
+}
+
+// setServiceInterval updates the maintenance interval of a category or service type
+// Zero months and zero km disable the interval.
+func setServiceInterval(dataJSON string) string {
+	var data struct {
+		ServiceType string `json:"service_type"`
+		Months      int    `json:"months"`
+		Kilometers  int    `json:"kilometers"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	interval := storage.ServiceInterval{Months: data.Months, Kilometers: data.Kilometers}
+	if err := storage.UpdateServiceInterval(data.ServiceType, interval); err != nil {
+		return jsonError(err)
+	}
+
+	return jsonSuccess()
+}
+
+// getMaintenanceReminders returns overdue and upcoming maintenance with customer phones
+// Filter: {"date": "2006-01-02", "days": 30, "km": 1000}; empty date = now, zero = default look-ahead
+func getMaintenanceReminders(filterJSON string) string {
+	var filter struct {
+		Date string `json:"date"`
+		Days int    `json:"days"`
+		Km   int    `json:"km"`
+	}
+	if filterJSON != "" {
+		if err := json.Unmarshal([]byte(filterJSON), &filter); err != nil {
+			return jsonError(err)
+		}
+	}
+
+	at, err := parseCashDate(filter.Date)
+	if err != nil {
+		return jsonError(err)
+	}
+	if filter.Days == 0 {
+		filter.Days = storage.DefaultReminderDays
+	}
+	if filter.Km == 0 {
+		filter.Km = storage.DefaultReminderKm
+	}
+
+	reminders, err := store.GetMaintenanceReminders(at, filter.Days, filter.Km)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(reminders)
+}
+
+// =============================================================================
+// Currency Functions
+// =============================================================================
+
+// getCurrencies returns the base currency and selectable currencies
+func getCurrencies() string {
+	return jsonMarshal(map[string]interface{}{
+		"base":       storage.GetBaseCurrency(),
+		"currencies": storage.GetCurrencies(),
+	})
+}
+
+// setBaseCurrency changes the base currency
+func setBaseCurrency(currency string) string {
+	if err := store.UpdateBaseCurrency(currency); err != nil {
+		return jsonError(err)
+	}
+	return jsonSuccess()
+}
+
+// listExchangeRates returns stored exchange rates, optionally for one currency
+func listExchangeRates(currency string) string {
+	rates, err := store.ListExchangeRates(currency)
+	if err != nil {
+		return jsonError(err)
+	}
+	return jsonMarshal(rates)
+}
+
+// saveExchangeRate stores a manually entered exchange rate
+func saveExchangeRate(dataJSON string) string {
+	var data struct {
+		Currency string  `json:"currency"`
+		DateStr  string  `json:"date"` // "2024-01-15"
+		Rate     float64 `json:"rate"`
+	}
+
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return jsonError(err)
+	}
+
+	var date time.Time // Boşsa bugün
+	if data.DateStr != "" {
+		var err error
+		if date, err = time.ParseInLocation("2006-01-02", data.DateStr, time.Local); err != nil {
+			return jsonError(fmt.Errorf("geçersiz tarih: %q", data.DateStr))
+		}
+	}
+
+	rate, err := store.SaveExchangeRate(data.Currency, date, data.Rate, storage.RateSourceManual)
+	if err != nil {
+		return jsonError(err)
+	}
+
+	return jsonMarshal(rate)
+}
+
+// deleteExchangeRate removes an exchange rate
+func deleteExchangeRate(id string) string {
+	if err := store.DeleteExchangeRate(id); err != nil {
+		return jsonError(err)
+	}
+	return jsonSuccess()
+}
+
+// importExchangeRates imports rates from file content ("date;currency;rate" per line)
+func importExchangeRates(content string) string {
+	imported, errors := store.ImportExchangeRates(strings.NewReader(content))
+
+	return jsonMarshal(map[string]interface{}{
+		"success":  true,
+		"imported": imported,
+		"errors":   errors,
+	})
+}
@@ -3,10 +3,12 @@ package storage
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/blevesearch/bleve/v2"
@@ -31,40 +33,140 @@ const (
 	UnitPacket = "paket"
 )
 
+// Discount types
+const (
+	DiscountTypePercent = "percent" // Yüzde indirim
+	DiscountTypeAmount  = "amount"  // Tutar indirimi
+)
+
+// Stock movement types
+const (
+	MovementTypeIn       = "in"       // Stok girişi
+	MovementTypeOut      = "out"      // Stok çıkışı
+	MovementTypeAdjust   = "adjust"   // Düzeltme (Amount işaretli: + artış, - azalış)
+	MovementTypeTransfer = "transfer" // Stok yerleri arası aktarım (toplam stok değişmez)
+)
+
+// Stock adjustment reasons
+const (
+	AdjustReasonReconciliation = "reconciliation" // Defter / stok mutabakatı
+	AdjustReasonStockCount     = "stock_count"    // Sayım farkı
+	AdjustReasonDamage         = "damage"         // Hasarlı / kullanılamaz
+	AdjustReasonLoss           = "loss"           // Kayıp
+	AdjustReasonCorrection     = "correction"     // Elle düzeltme
+	AdjustReasonReversal       = "reversal"       // Düzeltme hareketinin iptali
+)
+
 // Default categories
 var DefaultCategories = []string{"Yağ", "Filtre", "Sprey", "Fren", "Diğer"}
 
+// DefaultTaxRate - KDV rate (%) used when a category has no rate of its own
+const DefaultTaxRate = 20.0
+
+// DefaultCategoryTaxRates - Default KDV rates (%) per product category
+var DefaultCategoryTaxRates = map[string]float64{
+	"Yağ":    20,
+	"Filtre": 20,
+	"Sprey":  20,
+	"Fren":   20,
+	"Diğer":  20,
+}
+
 // Product - Ürün Kataloğu (Stok bilgisi dahil)
 type Product struct {
-	ID            string    `json:"id"`
-	Name          string    `json:"name"`           // Ürün adı (örn: "Motor Yağı 5W-30")
-	OEMNumber     string    `json:"oem_number"`     // OEM numarası
-	Brand         string    `json:"brand"`          // Marka (örn: "Castrol")
-	Category      string    `json:"category"`       // Kategori (örn: "Yağ", "Filtre")
-	Unit          string    `json:"unit"`           // Unit: adet, litre, kutu, paket
-	StockQuantity float64   `json:"stock_quantity"` // Current stock (decimal for litres)
-	CriticalStock int       `json:"critical_stock"` // Critical stock level (default: 3)
-	UsedCount     int       `json:"used_count"`     // Usage count
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`           // Ürün adı (örn: "Motor Yağı 5W-30")
+	OEMNumber     string  `json:"oem_number"`     // OEM numarası
+	Brand         string  `json:"brand"`          // Marka (örn: "Castrol")
+	Category      string  `json:"category"`       // Kategori (örn: "Yağ", "Filtre")
+	Unit          string  `json:"unit"`           // Unit: adet, litre, kutu, paket
+	StockQuantity float64 `json:"stock_quantity"` // Current stock (decimal for litres), total of all locations
+	CriticalStock int     `json:"critical_stock"` // Critical stock level (default: 3)
+
+	ReorderPoint        float64 `json:"reorder_point,omitempty"`         // Reorder below this level (0 = from critical stock)
+	ReorderTarget       float64 `json:"reorder_target,omitempty"`        // Order up to this level (0 = automatic)
+	PreferredSupplierID string  `json:"preferred_supplier_id,omitempty"` // Supplier for reorder suggestions
+	LeadTimeDays        int     `json:"lead_time_days,omitempty"`        // Supplier delivery time
+
+	ReservedQuantity  float64 `json:"reserved_quantity"`  // Held by confirmed orders
+	AvailableQuantity float64 `json:"available_quantity"` // StockQuantity - ReservedQuantity (derived)
+
+	LocationStock    map[string]float64 `json:"location_stock,omitempty"`    // Stock per location ID
+	LocationCritical map[string]int     `json:"location_critical,omitempty"` // Critical level per location ID (overrides CriticalStock)
+
+	LotTracking bool        `json:"lot_tracking"`   // Stock-in records lot and expiry; stock-out is FEFO
+	Lots        []*StockLot `json:"lots,omitempty"` // Lots on hand (lot tracking only)
+
+	UnitConversions []UnitConversion `json:"unit_conversions,omitempty"` // Purchase / sale units (e.g. 1 kutu = 12 adet)
+	PurchaseUnit    string           `json:"purchase_unit,omitempty"`    // Default stock-in unit (empty = Unit)
+	SaleUnit        string           `json:"sale_unit,omitempty"`        // Default order item unit (empty = Unit)
+
+	SerialTracking bool `json:"serial_tracking"` // Every unit is tracked by serial number
+	UsedCount      int  `json:"used_count"`      // Usage count
+
+	PurchasePrice    Money  `json:"purchase_price"`              // Supplier list price
+	PurchaseCurrency string `json:"purchase_currency,omitempty"` // Currency of PurchasePrice (empty = base)
+	LastCost         Money  `json:"last_cost"`                   // Unit cost of the last stock-in (base currency)
+	AverageCost      Money  `json:"average_cost"`                // Weighted average unit cost (base currency)
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // StockMovement - stock in/out records
 type StockMovement struct {
 	ID           string    `json:"id"`
 	ProductID    string    `json:"product_id"`
-	ProductName  string    `json:"product_name"`  // Denormalize - for quick display
-	MovementType string    `json:"movement_type"` // "in" or "out"
-	Amount       float64   `json:"amount"`        // Movement amount
-	Note         string    `json:"note"`          // e.g., "maintenance" or "plate"
+	ProductName  string    `json:"product_name"`     // Denormalize - for quick display
+	MovementType string    `json:"movement_type"`    // "in", "out", "adjust" or "transfer"
+	Amount       float64   `json:"amount"`           // Movement amount (signed for "adjust")
+	Reason       string    `json:"reason,omitempty"` // Adjustment reason
+	Note         string    `json:"note"`             // e.g., "maintenance"
 	Date         time.Time `json:"date"`
+
+	UnitCost     Money   `json:"unit_cost,omitempty"`     // Unit cost in base currency (in: purchase cost, out: average cost)
+	EnteredCost  Money   `json:"entered_cost,omitempty"`  // Unit cost as entered, in Currency and per Unit
+	Currency     string  `json:"currency,omitempty"`      // Currency of EnteredCost
+	ExchangeRate float64 `json:"exchange_rate,omitempty"` // Rate used to convert EnteredCost
+
+	LocationID   string `json:"location_id,omitempty"`    // Stock location (transfer: source)
+	ToLocationID string `json:"to_location_id,omitempty"` // Transfer destination
+
+	LotNumber       string          `json:"lot_number,omitempty"`        // Stock-in: received lot
+	ExpiryDate      *time.Time      `json:"expiry_date,omitempty"`       // Stock-in: expiry of the lot
+	LotAllocations  []LotAllocation `json:"lot_allocations,omitempty"`   // Lots consumed (out, adjust, transfer)
+	SerialNumbers   []string        `json:"serial_numbers,omitempty"`    // Serial-tracked products: units moved
+	OrderID         string          `json:"order_id,omitempty"`          // Stock-out: order delivered by this movement
+	PurchaseOrderID string          `json:"purchase_order_id,omitempty"` // Stock-in: purchase order received by this movement
+	VehicleID       string          `json:"vehicle_id,omitempty"`        // Stock-out: vehicle the parts went into
+	VehiclePlate    string          `json:"vehicle_plate,omitempty"`     // Denormalize
+
+	Unit       string  `json:"unit,omitempty"`        // Unit the amount was entered in (empty = base unit)
+	UnitAmount float64 `json:"unit_amount,omitempty"` // Amount as entered, in Unit
+	BaseUnit   string  `json:"base_unit,omitempty"`   // Product base unit Amount is in (at the time of the movement)
+
+	ReversalOf string     `json:"reversal_of,omitempty"` // Counter-movement: ID of the reversed movement
+	ReversedBy string     `json:"reversed_by,omitempty"` // Reversed movement: ID of its counter-movement
+	ReversedAt *time.Time `json:"reversed_at,omitempty"`
 }
 
-// BulkStockInfo - information for bulk stock operations
+// BulkStockInfo - information for single and bulk stock operations
 type BulkStockInfo struct {
-	ProductID string  `json:"product_id"`
-	Amount    float64 `json:"amount"`
-	Note      string  `json:"note"`
+	ProductID  string  `json:"product_id"`
+	Amount     float64 `json:"amount"`
+	Unit       string  `json:"unit"` // Unit of Amount and UnitCost (empty = base unit)
+	Note       string  `json:"note"`
+	UnitCost   Money   `json:"unit_cost"`   // Stock-in: purchase cost per unit (optional)
+	Currency   string  `json:"currency"`    // Currency of UnitCost (empty = base)
+	LocationID string  `json:"location_id"` // Stock location (empty = default)
+	LotNumber  string  `json:"lot_number"`  // Lot-tracked products: lot received or, for stock-out, lot to take from
+	ExpiryDate string  `json:"expiry_date"` // Stock-in: "2006-01-02"
+
+	SerialNumbers []string `json:"serial_numbers"` // Serial-tracked products: one per unit
+	OrderID       string   `json:"order_id"`       // Stock-out: delivered order
+
+	PurchaseOrderID string `json:"purchase_order_id"` // Stock-in: goods receipt against a purchase order
+	VehicleID       string `json:"vehicle_id"`        // Stock-out: vehicle the parts went into
 }
 
 // ProductListResult - Paginated product list response
@@ -91,39 +193,110 @@ type Customer struct {
 	Phone       string    `json:"phone"`
 	Address     string    `json:"address"`
 	Notes       string    `json:"notes"`
+	IsSupplier  bool      `json:"is_supplier"` // Tedarikçi rolü (satın alma siparişi verilebilir)
 	OrderCount  int       `json:"order_count"`
-	TotalAmount float64   `json:"total_amount"`
+	TotalAmount Money     `json:"total_amount"`
+	PaidAmount  Money     `json:"paid_amount"` // Alınan ödemeler (ana para birimi)
+	Balance     Money     `json:"balance"`     // Cari bakiye (pozitif = müşteri borçlu)
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 // OrderItem - Sipariş içindeki ürün
 type OrderItem struct {
-	ID          string  `json:"id"`
-	ProductName string  `json:"product_name"`
-	OEMNumber   string  `json:"oem_number"`
-	Quantity    int     `json:"quantity"`
-	UnitPrice   float64 `json:"unit_price"`
-	PartStatus  string  `json:"part_status"` // "original" veya "used"
-	TotalPrice  float64 `json:"total_price"`
+	ID          string   `json:"id"`
+	ProductID   string   `json:"product_id,omitempty"` // Katalog ürünü (bulunabildiyse)
+	ProductName string   `json:"product_name"`
+	OEMNumber   string   `json:"oem_number"`
+	Quantity    float64  `json:"quantity"`       // Litre ürünlerde ondalıklı, diğerlerinde tam sayı
+	Unit        string   `json:"unit,omitempty"` // Satış birimi (boşsa ürünün satış birimi, o da yoksa temel birim)
+	UnitPrice   Money    `json:"unit_price"`
+	PartStatus  string   `json:"part_status"`        // "original" veya "used"
+	TaxRate     *float64 `json:"tax_rate,omitempty"` // KDV oranı (%); boşsa %0 (eski kayıtlar)
+	UnitCost    Money    `json:"unit_cost"`          // Satış anındaki birim maliyet (ana para birimi, ağırlıklı ortalama)
+
+	LineType   string  `json:"line_type,omitempty"`   // part (boş), labor veya service
+	Hours      float64 `json:"hours,omitempty"`       // İşçilik: saat (= Quantity)
+	HourlyRate Money   `json:"hourly_rate,omitempty"` // İşçilik: saat ücreti (= UnitPrice)
+	Technician string  `json:"technician,omitempty"`  // İşçilik / hizmet: işi yapan usta
+
+	ServiceType string `json:"service_type,omitempty"` // Bakım türü (örn. "Yağ değişimi"); boşsa ürün kategorisi sayılır
+
+	BaseQuantity float64 `json:"base_quantity,omitempty"` // Quantity in the product's base unit (catalog products)
+
+	SerialNumbers []string `json:"serial_numbers,omitempty"` // Seri takipli ürünlerde satılan birimler
+
+	DiscountType       string  `json:"discount_type,omitempty"`  // "percent" veya "amount"
+	DiscountValue      float64 `json:"discount_value,omitempty"` // Yüzde veya tutar
+	ListTotal          Money   `json:"list_total"`               // Quantity × UnitPrice (indirimsiz)
+	DiscountAmount     Money   `json:"discount_amount"`          // Kalem indirimi tutarı
+	TotalPrice         Money   `json:"total_price"`              // Kalem indirimi sonrası tutar (fiyat girişine göre KDV dahil/hariç)
+	OrderDiscountShare Money   `json:"order_discount_share"`     // Sipariş indiriminden bu kaleme düşen pay
+	NetAmount          Money   `json:"net_amount"`               // Tüm indirimler sonrası KDV hariç tutar
+	TaxAmount          Money   `json:"tax_amount"`               // KDV tutarı
+}
+
+// TaxBreakdown - KDV oranına göre matrah ve vergi toplamı
+type TaxBreakdown struct {
+	Rate float64 `json:"rate"` // KDV oranı (%)
+	Base Money   `json:"base"` // KDV hariç matrah
+	Tax  Money   `json:"tax"`  // KDV tutarı
 }
 
 // Order - Sipariş
 type Order struct {
-	ID           string      `json:"id"`
-	Title        string      `json:"title"`         // Sipariş başlığı (örn: "Aralık İhale 1")
-	CustomerID   string      `json:"customer_id"`   // Müşteri ID
-	CustomerName string      `json:"customer_name"` // Müşteri/Tedarikçi adı (denormalize)
-	Items        []OrderItem `json:"items"`
-	GrandTotal   float64     `json:"grand_total"`
-	CreatedAt    time.Time   `json:"created_at"`
-	UpdatedAt    time.Time   `json:"updated_at"`
+	ID               string      `json:"id"`
+	Title            string      `json:"title"`              // Sipariş başlığı (örn: "Aralık İhale 1")
+	CustomerID       string      `json:"customer_id"`        // Müşteri ID
+	CustomerName     string      `json:"customer_name"`      // Müşteri/Tedarikçi adı (denormalize)
+	Currency         string      `json:"currency"`           // Sipariş para birimi (boşsa ana para birimi)
+	PricesIncludeTax bool        `json:"prices_include_tax"` // Birim fiyatlar KDV dahil mi girildi
+	Items            []OrderItem `json:"items"`
+
+	DiscountType        string  `json:"discount_type,omitempty"`  // Sipariş indirimi: "percent" veya "amount"
+	DiscountValue       float64 `json:"discount_value,omitempty"` // Yüzde veya tutar
+	ListTotal           Money   `json:"list_total"`               // İndirimsiz kalem toplamı
+	ItemDiscountTotal   Money   `json:"item_discount_total"`      // Kalem indirimleri toplamı
+	OrderDiscountAmount Money   `json:"order_discount_amount"`    // Sipariş indirimi tutarı
+	DiscountTotal       Money   `json:"discount_total"`           // Tüm indirimler
+
+	Subtotal     Money          `json:"subtotal"`      // KDV hariç ara toplam
+	TaxTotal     Money          `json:"tax_total"`     // Toplam KDV
+	TaxBreakdown []TaxBreakdown `json:"tax_breakdown"` // Orana göre KDV dökümü
+	GrandTotal   Money          `json:"grand_total"`   // KDV dahil genel toplam
+	PartsTotal   Money          `json:"parts_total"`   // Parça kalemleri (KDV hariç, indirimler sonrası)
+	LaborTotal   Money          `json:"labor_total"`   // İşçilik ve hizmet kalemleri (KDV hariç, indirimler sonrası)
+
+	ExchangeRate   float64 `json:"exchange_rate"`    // Sipariş tarihindeki kur (1 birim = ? ana para birimi)
+	BaseSubtotal   Money   `json:"base_subtotal"`    // Ana para biriminde KDV hariç ara toplam
+	BaseGrandTotal Money   `json:"base_grand_total"` // Ana para biriminde genel toplam
+	CostTotal      Money   `json:"cost_total"`       // Kalem maliyetleri toplamı (ana para birimi)
+	Profit         Money   `json:"profit"`           // BaseSubtotal - CostTotal
+	MarginPercent  float64 `json:"margin_percent"`   // Profit / BaseSubtotal × 100
+
+	Status      string     `json:"status,omitempty"`       // draft, confirmed (stok ayırır), delivered; boş = eski kayıt
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"` // Teslim (stok çıkışı) zamanı
+
+	VehicleID     string `json:"vehicle_id,omitempty"`     // Servis verilen araç
+	VehiclePlate  string `json:"vehicle_plate,omitempty"`  // Denormalize
+	MileageIn     int    `json:"mileage_in,omitempty"`     // İş emri: geliş km'si
+	MileageOut    int    `json:"mileage_out,omitempty"`    // İş emri: çıkış km'si
+	Complaint     string `json:"complaint,omitempty"`      // İş emri: müşteri şikayeti
+	WorkPerformed string `json:"work_performed,omitempty"` // İş emri: yapılan işlemler
+
+	PaidAmount        Money  `json:"paid_amount"`        // Ödemelerden dağıtılan (ana para birimi)
+	OutstandingAmount Money  `json:"outstanding_amount"` // BaseGrandTotal - PaidAmount
+	PaymentStatus     string `json:"payment_status"`     // unpaid, partial, paid
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // BleveStore - Bleve tabanlı depolama
 type BleveStore struct {
 	index    bleve.Index
 	dataPath string
+	mu       sync.Mutex // Stok miktarını değiştiren işlemleri ve sayım oturumlarını sıralar
 }
 
 // NewBleveStore - Yeni Bleve store oluşturur
@@ -154,10 +327,18 @@ func NewBleveStore() (*BleveStore, error) {
 		}
 	}
 
-	return &BleveStore{
+	store := &BleveStore{
 		index:    index,
 		dataPath: dataPath,
-	}, nil
+	}
+
+	// Mevcut stoğu varsayılan yere taşı
+	if err := store.ensureDefaultLocation(); err != nil {
+		index.Close()
+		return nil, fmt.Errorf("stok yeri hazırlanamadı: %w", err)
+	}
+
+	return store, nil
 }
 
 // buildIndexMapping - Elasticsearch benzeri index mapping
@@ -223,16 +404,76 @@ func (s *BleveStore) SaveOrder(order *Order) error {
 		order.CreatedAt = time.Now()
 	}
 
+	// Yeni sipariş taslak (varsayılan) veya onaylı olabilir; teslim DeliverOrder ile yapılır.
+	// Boş durum yalnızca durumlardan önce kaydedilmiş eski siparişlerde kalır.
+	if order.Status == "" {
+		order.Status = OrderStatusDraft
+	}
+	if order.Status != OrderStatusDraft && order.Status != OrderStatusConfirmed {
+		return fmt.Errorf("geçersiz sipariş durumu: %q", order.Status)
+	}
+	order.DeliveredAt = nil
+
+	// Araç (müşterisi boşsa aracın sahibi atanır) ve iş emri alanları
+	if err := s.applyOrderVehicle(order, nil); err != nil {
+		return err
+	}
+	if err := order.prepareWorkOrder(); err != nil {
+		return err
+	}
+
+	// Kalemleri katalogla eşleştir, miktarları doğrula, KDV oranlarını ata
+	if err := s.prepareOrderItems(order, nil); err != nil {
+		return err
+	}
+
 	// Toplamları hesapla
 	order.CalculateGrandTotal()
 
-	// JSON'a çevir ve kaydet
+	// Sipariş tarihindeki kurla ana para birimi toplamları
+	if err := s.applyExchangeRate(order); err != nil {
+		return err
+	}
+
+	// Ödenen tutar; toplam düştüyse fazla dağıtımlar geri alınır (sipariş yazıldıktan sonra kaydedilir)
+	trimmed, err := s.applyOrderPayments(order)
+	if err != nil {
+		return err
+	}
+
+	if err := s.writeOrder(order); err != nil {
+		return err
+	}
+	if err := s.savePayments(trimmed); err != nil {
+		return err
+	}
+
+	// Seri numaralarını siparişe bağla
+	if err := s.assignOrderSerials(order); err != nil {
+		return err
+	}
+
+	// Aracın son bilinen km'si
+	if err := s.recordVehicleMileage(order.VehicleID, order.lastMileage(), order.CreatedAt); err != nil {
+		return err
+	}
+
+	// Onaylı sipariş stok ayırır
+	if order.reservesStock() {
+		return s.refreshReservations()
+	}
+	return nil
+}
+
+// writeOrder - Siparişi indexler ve JSON dosyasına yazar
+func (s *BleveStore) writeOrder(order *Order) error {
+	// JSON'a çevir
 	data, err := json.Marshal(order)
 	if err != nil {
 		return fmt.Errorf("JSON dönüştürme hatası: %w", err)
 	}
 
-	// Bleve'e indexle
+	// Bleve'e indexle (aynı ID ile tekrar indexlemek günceller)
 	if err := s.index.Index(order.ID, order); err != nil {
 		return fmt.Errorf("indexleme hatası: %w", err)
 	}
@@ -262,11 +503,32 @@ func (s *BleveStore) GetOrder(id string) (*Order, error) {
 		return nil, fmt.Errorf("JSON çözümleme hatası: %w", err)
 	}
 
+	// Türetilmiş toplamları yenile (eski kayıtlarda indirim/KDV/kur alanları yok)
+	order.CalculateGrandTotal()
+	order.calculateBaseTotals()
+	order.updatePaymentStatus()
+
 	return &order, nil
 }
 
 // DeleteOrder - Siparişi sil
 func (s *BleveStore) DeleteOrder(id string) error {
+	// Seri numaralarını serbest bırak
+	reserved := false
+	customerID := ""
+	if order, err := s.GetOrder(id); err == nil {
+		if err := s.releaseOrderSerials(order); err != nil {
+			return err
+		}
+		reserved = order.reservesStock()
+		customerID = order.CustomerID
+	}
+
+	// Ödeme dağıtımlarını kaldır
+	if err := s.removeOrderAllocations(id); err != nil {
+		return err
+	}
+
 	// Bleve'den sil
 	if err := s.index.Delete(id); err != nil {
 		return fmt.Errorf("index silme hatası: %w", err)
@@ -278,6 +540,15 @@ func (s *BleveStore) DeleteOrder(id string) error {
 		return fmt.Errorf("dosya silme hatası: %w", err)
 	}
 
+	// Müşterinin sipariş sayısı ve cari bakiyesi
+	if err := s.UpdateCustomerStats(customerID); err != nil {
+		return err
+	}
+
+	// Rezervasyonu bırak
+	if reserved {
+		return s.refreshReservations()
+	}
 	return nil
 }
 
@@ -350,30 +621,60 @@ func (s *BleveStore) UpdateOrder(order *Order) error {
 		return fmt.Errorf("sipariş bulunamadı: %w", err)
 	}
 
-	// CreatedAt'ı koru, UpdatedAt'ı güncelle
+	// CreatedAt'ı ve durumu koru (durum SetOrderStatus / DeliverOrder ile değişir), UpdatedAt'ı güncelle
 	order.CreatedAt = existingOrder.CreatedAt
+	order.Status = existingOrder.Status
+	order.DeliveredAt = existingOrder.DeliveredAt
 	order.UpdatedAt = time.Now()
 
+	// Araç (müşterisi boşsa aracın sahibi atanır) ve iş emri alanları
+	if err := s.applyOrderVehicle(order, existingOrder); err != nil {
+		return err
+	}
+	if err := order.prepareWorkOrder(); err != nil {
+		return err
+	}
+
+	// Kalemleri katalogla eşleştir, miktarları doğrula, KDV oranlarını ata
+	if err := s.prepareOrderItems(order, existingOrder); err != nil {
+		return err
+	}
+
 	// Toplamları hesapla
 	order.CalculateGrandTotal()
 
-	// JSON'a çevir
-	data, err := json.Marshal(order)
+	// Sipariş tarihindeki kurla ana para birimi toplamları
+	if err := s.applyExchangeRate(order); err != nil {
+		return err
+	}
+
+	// Ödenen tutar; toplam düştüyse fazla dağıtımlar geri alınır (sipariş yazıldıktan sonra kaydedilir)
+	trimmed, err := s.applyOrderPayments(order)
 	if err != nil {
-		return fmt.Errorf("JSON dönüştürme hatası: %w", err)
+		return err
 	}
 
-	// Bleve'e güncelle (aynı ID ile tekrar indexle)
-	if err := s.index.Index(order.ID, order); err != nil {
-		return fmt.Errorf("indexleme hatası: %w", err)
+	if err := s.writeOrder(order); err != nil {
+		return err
+	}
+	if err := s.savePayments(trimmed); err != nil {
+		return err
 	}
 
-	// JSON dosyasını güncelle
-	orderFile := filepath.Join(s.dataPath, "orders", order.ID+".json")
-	if err := os.WriteFile(orderFile, data, 0644); err != nil {
-		return fmt.Errorf("dosya yazma hatası: %w", err)
+	// Seri numaralarını siparişe bağla
+	if err := s.assignOrderSerials(order); err != nil {
+		return err
+	}
+
+	// Aracın son bilinen km'si
+	if err := s.recordVehicleMileage(order.VehicleID, order.lastMileage(), order.CreatedAt); err != nil {
+		return err
 	}
 
+	// Onaylı siparişte kalem değişikliği rezervasyonu değiştirir
+	if order.reservesStock() {
+		return s.refreshReservations()
+	}
 	return nil
 }
 
@@ -403,7 +704,7 @@ func (s *BleveStore) SearchOrders(searchTerm string) ([]*Order, error) {
 }
 
 // SearchOrdersAdvanced - Gelişmiş sipariş arama
-func (s *BleveStore) SearchOrdersAdvanced(productName, oemNumber, customerName string, minQty, maxQty int, minTotal, maxTotal, minUnitPrice, maxUnitPrice float64, dateFilter, startDate, endDate string) ([]*Order, error) {
+func (s *BleveStore) SearchOrdersAdvanced(productName, oemNumber, customerName string, minQty, maxQty float64, minTotal, maxTotal, minUnitPrice, maxUnitPrice float64, dateFilter, startDate, endDate string) ([]*Order, error) {
 	allOrders, err := s.ListOrders()
 	if err != nil {
 		return nil, err
@@ -451,10 +752,10 @@ func (s *BleveStore) SearchOrdersAdvanced(productName, oemNumber, customerName s
 		}
 
 		// Toplam tutar filtresi
-		if minTotal > 0 && order.GrandTotal < minTotal {
+		if minTotal > 0 && order.GrandTotal < MoneyFromFloat(minTotal) {
 			continue
 		}
-		if maxTotal > 0 && order.GrandTotal > maxTotal {
+		if maxTotal > 0 && order.GrandTotal > MoneyFromFloat(maxTotal) {
 			continue
 		}
 
@@ -465,8 +766,8 @@ func (s *BleveStore) SearchOrdersAdvanced(productName, oemNumber, customerName s
 			oemMatch := oemNumber == "" || strings.Contains(strings.ToLower(item.OEMNumber), strings.ToLower(oemNumber))
 			minQtyMatch := minQty == 0 || item.Quantity >= minQty
 			maxQtyMatch := maxQty == 0 || item.Quantity <= maxQty
-			minUnitPriceMatch := minUnitPrice == 0 || item.UnitPrice >= minUnitPrice
-			maxUnitPriceMatch := maxUnitPrice == 0 || item.UnitPrice <= maxUnitPrice
+			minUnitPriceMatch := minUnitPrice == 0 || item.UnitPrice >= MoneyFromFloat(minUnitPrice)
+			maxUnitPriceMatch := maxUnitPrice == 0 || item.UnitPrice <= MoneyFromFloat(maxUnitPrice)
 
 			if productMatch && oemMatch && minQtyMatch && maxQtyMatch && minUnitPriceMatch && maxUnitPriceMatch {
 				matchProduct = true
@@ -487,23 +788,274 @@ func (s *BleveStore) SearchOrdersAdvanced(productName, oemNumber, customerName s
 	return filtered, nil
 }
 
-// CalculateTotalPrice - Ürün toplam fiyatını hesaplar
-func (item *OrderItem) CalculateTotalPrice() {
-	item.TotalPrice = float64(item.Quantity) * item.UnitPrice
+// EffectiveTaxRate - Kalemin KDV oranı (%); oran atanmamışsa 0
+func (item *OrderItem) EffectiveTaxRate() float64 {
+	if item.TaxRate == nil {
+		return 0
+	}
+	return *item.TaxRate
 }
 
-// CalculateGrandTotal - Siparişin genel toplamını hesaplar
+// calculateDiscount - İndirim tutarını hesaplar; indirim hiçbir zaman base'i aşmaz
+// Yüzde indirim base'in yüzdesi olarak kuruşa yuvarlanır, tutar indirimi kuruşa yuvarlanarak alınır.
+func calculateDiscount(discountType string, value float64, base Money) Money {
+	if value <= 0 || base <= 0 {
+		return 0
+	}
+
+	var discount Money
+	switch discountType {
+	case DiscountTypePercent:
+		if value > 100 {
+			value = 100
+		}
+		discount = base.Percent(value)
+	case DiscountTypeAmount:
+		discount = MoneyFromFloat(value)
+	}
+
+	return discount.Min(base)
+}
+
+// CalculateTotalPrice - Kalem tutarlarını hesaplar
+// Uygulama sırası:
+//  1. ListTotal = Quantity × UnitPrice
+//  2. Kalem indirimi (yüzde veya tutar) ListTotal üzerinden → TotalPrice
+//  3. Sipariş indiriminin kaleme düşen payı (OrderDiscountShare) TotalPrice'tan düşülür
+//  4. KDV kalan tutar üzerinden hesaplanır; pricesIncludeTax true ise KDV tutarın içinden ayrılır
+//
+// İndirimler fiyatların girildiği esasa göre uygulanır (KDV dahil fiyatta KDV dahil indirim).
+// Her adım kuruşa yuvarlanır (bkz. Money).
+func (item *OrderItem) CalculateTotalPrice(pricesIncludeTax bool) {
+	item.ListTotal = item.UnitPrice.MulQuantity(item.Quantity)
+	item.DiscountAmount = calculateDiscount(item.DiscountType, item.DiscountValue, item.ListTotal)
+	item.TotalPrice = item.ListTotal - item.DiscountAmount
+	item.calculateTax(pricesIncludeTax)
+}
+
+// calculateTax - Sipariş indirimi payı düşüldükten sonra KDV ayrımını yapar
+func (item *OrderItem) calculateTax(pricesIncludeTax bool) {
+	amount := item.TotalPrice - item.OrderDiscountShare
+
+	rate := item.EffectiveTaxRate()
+	if pricesIncludeTax {
+		item.NetAmount, item.TaxAmount = amount.ExtractTax(rate)
+	} else {
+		item.NetAmount = amount
+		item.TaxAmount = amount.Percent(rate)
+	}
+}
+
+// CalculateGrandTotal - Siparişin indirimlerini, ara toplamını, KDV dökümünü ve genel toplamını hesaplar
+// Sipariş indirimi, kalem indirimleri sonrası toplam üzerinden hesaplanır ve kalemlere
+// tutarları oranında dağıtılır (yuvarlama farkı son kaleme); böylece KDV her oran için
+// indirimli matrah üzerinden bulunur. Toplamlar yuvarlanmış kalem değerlerinin toplamıdır.
 func (order *Order) CalculateGrandTotal() {
-	var total float64
+	var listTotal, itemDiscountTotal, lineTotal Money
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.OrderDiscountShare = 0
+		item.CalculateTotalPrice(order.PricesIncludeTax)
+		listTotal += item.ListTotal
+		itemDiscountTotal += item.DiscountAmount
+		lineTotal += item.TotalPrice
+	}
+
+	// Sipariş indirimini kalemlere dağıt (kalan kuruş son kaleme)
+	orderDiscount := calculateDiscount(order.DiscountType, order.DiscountValue, lineTotal)
+	lastIndex := -1
+	for i := range order.Items {
+		if order.Items[i].TotalPrice > 0 {
+			lastIndex = i
+		}
+	}
+	remaining := orderDiscount
+	for i := range order.Items {
+		item := &order.Items[i]
+		if orderDiscount == 0 || item.TotalPrice <= 0 {
+			continue
+		}
+		share := orderDiscount.MulRatio(item.TotalPrice, lineTotal)
+		if i == lastIndex || share > remaining {
+			share = remaining
+		}
+		item.OrderDiscountShare = share
+		remaining -= share
+	}
+
+	var subtotal, taxTotal Money
+	byRate := make(map[float64]*TaxBreakdown)
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.calculateTax(order.PricesIncludeTax)
+		subtotal += item.NetAmount
+		taxTotal += item.TaxAmount
+
+		rate := item.EffectiveTaxRate()
+		if _, ok := byRate[rate]; !ok {
+			byRate[rate] = &TaxBreakdown{Rate: rate}
+		}
+		byRate[rate].Base += item.NetAmount
+		byRate[rate].Tax += item.TaxAmount
+	}
+
+	breakdown := make([]TaxBreakdown, 0, len(byRate))
+	for _, b := range byRate {
+		breakdown = append(breakdown, *b)
+	}
+	sort.Slice(breakdown, func(i, j int) bool {
+		return breakdown[i].Rate < breakdown[j].Rate
+	})
+
+	order.ListTotal = listTotal
+	order.ItemDiscountTotal = itemDiscountTotal
+	order.OrderDiscountAmount = orderDiscount
+	order.DiscountTotal = itemDiscountTotal + orderDiscount
+	order.Subtotal = subtotal
+	order.TaxTotal = taxTotal
+	order.TaxBreakdown = breakdown
+	order.GrandTotal = subtotal + taxTotal
+	order.calculateLineTypeTotals()
+}
+
+// prepareOrderItems - Kalemleri kayıt öncesi hazırlar
+//   - Katalog ürünü bulunursa ProductID ve birim atanır (bulunamazsa birim "adet"); kalemin
+//     birimi ürünün dönüşümlerinden biri olmalı, miktar BaseQuantity'ye çevrilir
+//   - Miktar birim kuralına göre doğrulanır (bkz. ValidateQuantity)
+//   - Yeni kalemlere ürünün o anki ortalama maliyeti (kalem birimi başına) yazılır, mevcut kalemler maliyetini korur
+//   - Oranı boş kalemlere KDV oranı atanır: mevcut siparişte aynı ID ile bulunan kalemler
+//     eski oranını korur (eski kayıtlar %0 kalır), yeni kalemler ürün kategorisinin oranını alır
+func (s *BleveStore) prepareOrderItems(order *Order, existing *Order) error {
+	existingItems := make(map[string]*OrderItem)
+	if existing != nil {
+		for i := range existing.Items {
+			existingItems[existing.Items[i].ID] = &existing.Items[i]
+		}
+	}
+
+	products, err := s.ListProducts()
+	if err != nil {
+		return err
+	}
+	productByID := make(map[string]*Product, len(products))
+	for _, p := range products {
+		productByID[p.ID] = p
+	}
+
 	for i := range order.Items {
-		order.Items[i].CalculateTotalPrice()
-		total += order.Items[i].TotalPrice
+		item := &order.Items[i]
+
+		old, isExisting := existingItems[item.ID]
+
+		category := ""
+		item.BaseQuantity = 0
+		item.ServiceType = strings.TrimSpace(item.ServiceType)
+		if item.LineType == LineTypePart {
+			item.LineType = ""
+		}
+		if !item.IsPart() {
+			// İşçilik / hizmet: katalog, stok ve maliyet yok
+			if category, err = item.prepareServiceLine(); err != nil {
+				return err
+			}
+		} else if p := findCatalogProduct(products, item); p != nil {
+			item.ProductID = p.ID
+			category = p.Category
+			if item.Unit == "" {
+				item.Unit = p.SaleUnit
+			}
+			if item.Unit == "" {
+				item.Unit = p.Unit
+			}
+			factor, ok := p.UnitFactor(item.Unit)
+			if !ok {
+				return fmt.Errorf("%s: %q biriminden %q birimine dönüşüm tanımlı değil", item.ProductName, item.Unit, p.Unit)
+			}
+
+			if isExisting && old.UnitCost > 0 {
+				item.UnitCost = old.UnitCost
+			} else if item.UnitCost == 0 {
+				item.UnitCost = p.AverageCost
+				if item.UnitCost == 0 {
+					item.UnitCost = p.LastCost
+				}
+				item.UnitCost = item.UnitCost.MulRate(factor)
+			}
+
+			if item.BaseQuantity, err = p.ToBaseQuantity(item.Quantity, item.Unit); err != nil {
+				return fmt.Errorf("%s: %w", item.ProductName, err)
+			}
+		} else if item.Unit == "" {
+			item.Unit = UnitPiece
+		} else if !isValidUnit(item.Unit) {
+			return fmt.Errorf("%s: geçersiz birim: %q", item.ProductName, item.Unit)
+		}
+
+		if item.IsPart() {
+			item.Hours = 0
+			item.HourlyRate = 0
+			item.Technician = ""
+			if err := ValidateQuantity(item.Quantity, item.Unit); err != nil {
+				return fmt.Errorf("%s: %w", item.ProductName, err)
+			}
+		}
+
+		if p := productByID[item.ProductID]; p != nil {
+			if err := s.validateOrderSerials(order, item, p); err != nil {
+				return err
+			}
+		}
+
+		if item.TaxRate != nil {
+			continue
+		}
+		if isExisting {
+			item.TaxRate = old.TaxRate
+			continue
+		}
+		rate := GetCategoryTaxRate(category)
+		item.TaxRate = &rate
 	}
-	order.GrandTotal = total
+
+	return nil
+}
+
+// findCatalogProduct - Sipariş kalemine karşılık gelen katalog ürününü bulur
+// Önce ürün ID'si, sonra ad + OEM, en son yalnızca ad ile eşleştirir
+func findCatalogProduct(products []*Product, item *OrderItem) *Product {
+	if item.ProductID != "" {
+		for _, p := range products {
+			if p.ID == item.ProductID {
+				return p
+			}
+		}
+	}
+
+	name := strings.ToLower(strings.TrimSpace(item.ProductName))
+	oem := strings.ToLower(strings.TrimSpace(item.OEMNumber))
+	if oem == "-" {
+		oem = ""
+	}
+
+	var nameMatch *Product
+	for _, p := range products {
+		if strings.ToLower(p.Name) != name {
+			continue
+		}
+		if strings.ToLower(p.OEMNumber) == oem {
+			return p
+		}
+		if nameMatch == nil {
+			nameMatch = p
+		}
+	}
+
+	return nameMatch
 }
 
 // NewOrderItem - Yeni sipariş kalemi oluşturur
-func NewOrderItem(productName, oemNumber string, quantity int, unitPrice float64, partStatus string) OrderItem {
+func NewOrderItem(productName, oemNumber string, quantity float64, unitPrice Money, partStatus string) OrderItem {
 	item := OrderItem{
 		ID:          uuid.New().String(),
 		ProductName: productName,
@@ -512,7 +1064,7 @@ func NewOrderItem(productName, oemNumber string, quantity int, unitPrice float64
 		UnitPrice:   unitPrice,
 		PartStatus:  partStatus,
 	}
-	item.CalculateTotalPrice()
+	item.CalculateTotalPrice(false)
 	return item
 }
 
@@ -726,10 +1278,27 @@ func (s *BleveStore) UpdateCustomerStats(customerID string) error {
 		return err
 	}
 
-	customer.OrderCount = len(orders)
+	// Taslak ve teslim bekleyen siparişler satış sayılmaz
+	customer.OrderCount = 0
 	customer.TotalAmount = 0
 	for _, order := range orders {
-		customer.TotalAmount += order.GrandTotal
+		if !order.isSale() {
+			continue
+		}
+		customer.OrderCount++
+		customer.TotalAmount += order.BaseGrandTotal
+	}
+
+	// Cari bakiye
+	entries, err := s.ledgerEntries(customerID)
+	if err != nil {
+		return err
+	}
+	customer.PaidAmount = 0
+	customer.Balance = 0
+	for _, entry := range entries {
+		customer.PaidAmount += entry.Credit
+		customer.Balance += entry.Debit - entry.Credit
 	}
 
 	return s.SaveCustomer(customer)
@@ -752,6 +1321,7 @@ func NewCustomer(name string) *Customer {
 // SaveProduct - Ürünü kaydet
 func (s *BleveStore) SaveProduct(product *Product) error {
 	product.UpdatedAt = time.Now()
+	product.AvailableQuantity = product.Available()
 
 	data, err := json.Marshal(product)
 	if err != nil {
@@ -787,6 +1357,7 @@ func (s *BleveStore) GetProduct(id string) (*Product, error) {
 	if err := json.Unmarshal(data, &product); err != nil {
 		return nil, fmt.Errorf("JSON çözümleme hatası: %w", err)
 	}
+	product.AvailableQuantity = product.Available()
 
 	return &product, nil
 }
@@ -859,7 +1430,7 @@ func (s *BleveStore) ListProductsPaginated(page, pageSize int, filter ProductFil
 			if threshold == 0 {
 				threshold = 3
 			}
-			if int(p.StockQuantity) >= threshold {
+			if int(p.Available()) >= threshold {
 				continue
 			}
 		}
@@ -1003,14 +1574,7 @@ func (s *BleveStore) CreateProductFull(name, oemNumber, brand, category, unit st
 	}
 
 	// Unit validation
-	validUnit := false
-	for _, u := range GetUnits() {
-		if u == unit {
-			validUnit = true
-			break
-		}
-	}
-	if !validUnit {
+	if !isValidUnit(unit) {
 		unit = UnitPiece
 	}
 
@@ -1029,17 +1593,35 @@ func (s *BleveStore) CreateProductFull(name, oemNumber, brand, category, unit st
 		Brand:         strings.TrimSpace(brand),
 		Category:      strings.TrimSpace(category),
 		Unit:          unit,
-		StockQuantity: stockQuantity,
 		CriticalStock: criticalStock,
 		UsedCount:     0,
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}
+	product.applyStockDelta(DefaultLocationID, stockQuantity)
 
 	if err := s.SaveProduct(product); err != nil {
 		return nil, err
 	}
 
+	// Opening stock goes through the ledger too
+	if stockQuantity > 0 {
+		movement := &StockMovement{
+			ID:           uuid.New().String(),
+			ProductID:    product.ID,
+			ProductName:  product.Name,
+			MovementType: MovementTypeIn,
+			Amount:       stockQuantity,
+			Note:         "Açılış stoku",
+			Date:         product.CreatedAt,
+			LocationID:   DefaultLocationID,
+			BaseUnit:     product.Unit,
+		}
+		if err := s.SaveStockMovement(movement); err != nil {
+			return nil, err
+		}
+	}
+
 	return product, nil
 }
 
@@ -1056,29 +1638,25 @@ func (s *BleveStore) IncrementProductUsage(productID string) error {
 
 // UpdateProduct - Update product with all fields
 func (s *BleveStore) UpdateProduct(id, name, oemNumber, brand, category, unit string, criticalStock int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	product, err := s.GetProduct(id)
 	if err != nil {
 		return err
 	}
 
+	oldUnit := product.Unit
+
 	product.Name = strings.TrimSpace(name)
 	product.OEMNumber = strings.TrimSpace(oemNumber)
 	product.Brand = strings.TrimSpace(brand)
 	product.Category = strings.TrimSpace(category)
 
-	// Unit change validation
-	if unit != "" {
-		validUnit := false
-		for _, u := range GetUnits() {
-			if u == unit {
-				validUnit = true
-				break
-			}
-		}
-		if validUnit {
-			product.Unit = unit
-			// Normalize stock quantity when unit changes
-			product.StockQuantity = NormalizeQuantity(product.StockQuantity, unit)
+	// Unit change: stock, lots and costs are converted to the new base unit
+	if unit != "" && isValidUnit(unit) {
+		if err := product.changeBaseUnit(unit); err != nil {
+			return err
 		}
 	}
 
@@ -1088,6 +1666,35 @@ func (s *BleveStore) UpdateProduct(id, name, oemNumber, brand, category, unit st
 
 	product.UpdatedAt = time.Now()
 
+	if product.Unit != oldUnit {
+		if err := s.stampMovementBaseUnit(product.ID, oldUnit); err != nil {
+			return err
+		}
+	}
+
+	return s.SaveProduct(product)
+}
+
+// UpdateProductPurchasePrice - Update supplier purchase price and its currency
+func (s *BleveStore) UpdateProductPurchasePrice(id string, price Money, currency string) error {
+	product, err := s.GetProduct(id)
+	if err != nil {
+		return err
+	}
+
+	if price < 0 {
+		return fmt.Errorf("purchase price cannot be negative")
+	}
+
+	currency, err = normalizeCurrency(currency)
+	if err != nil {
+		return err
+	}
+
+	product.PurchasePrice = price
+	product.PurchaseCurrency = currency
+	product.UpdatedAt = time.Now()
+
 	return s.SaveProduct(product)
 }
 
@@ -1133,6 +1740,15 @@ func (s *BleveStore) UpdateCustomer(id, name, phone string) error {
 
 // DeleteCustomer - Müşteriyi sil
 func (s *BleveStore) DeleteCustomer(id string) error {
+	// Kayıtlı aracı olan müşteri silinemez (araçlar önce aktarılmalı)
+	vehicles, err := s.ListVehicles(id)
+	if err != nil {
+		return err
+	}
+	if len(vehicles) > 0 {
+		return fmt.Errorf("müşterinin %d kayıtlı aracı var; önce araçları silin veya başka müşteriye aktarın", len(vehicles))
+	}
+
 	// Index'ten sil
 	if err := s.index.Delete(id); err != nil {
 		return err
@@ -1147,6 +1763,32 @@ func (s *BleveStore) DeleteCustomer(id string) error {
 // Stok Hareket İşlemleri
 // ============================================
 
+// QuantityDecimals - Allowed decimal places for a unit (litre: 1, others: whole numbers)
+func QuantityDecimals(unit string) int {
+	if unit == UnitLitre {
+		return 1
+	}
+	return 0
+}
+
+// ValidateQuantity - Checks that a quantity is positive and fits the unit's decimal rule
+func ValidateQuantity(quantity float64, unit string) error {
+	if quantity <= 0 {
+		return fmt.Errorf("quantity must be greater than zero")
+	}
+
+	scale := math.Pow10(QuantityDecimals(unit))
+	scaled := quantity * scale
+	if math.Abs(scaled-math.Round(scaled)) > 1e-6 {
+		if QuantityDecimals(unit) == 0 {
+			return fmt.Errorf("quantity must be a whole number for unit %q", unit)
+		}
+		return fmt.Errorf("quantity can have at most %d decimal place(s) for unit %q", QuantityDecimals(unit), unit)
+	}
+
+	return nil
+}
+
 // NormalizeQuantity - Normalize quantity according to unit
 // Rounds to integer for non-litre units
 func NormalizeQuantity(quantity float64, unit string) float64 {
@@ -1160,79 +1802,259 @@ func NormalizeQuantity(quantity float64, unit string) float64 {
 
 // StockIn - Add stock to product and create movement record
 func (s *BleveStore) StockIn(productID string, amount float64, note string) error {
-	product, err := s.GetProduct(productID)
+	return s.StockInEntry(BulkStockInfo{ProductID: productID, Amount: amount, Note: note})
+}
+
+// StockInEntry - Add stock with optional purchase cost and create movement record
+// The cost is converted to the base currency with today's rate and updates the
+// product's last cost and weighted average cost.
+func (s *BleveStore) StockInEntry(entry BulkStockInfo) error {
+	_, err := s.receiveStock(entry)
+	return err
+}
+
+// receiveStock - StockInEntry; oluşan hareketi de döner (mal kabulü için)
+func (s *BleveStore) receiveStock(entry BulkStockInfo) (*StockMovement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, err := s.GetProduct(entry.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("product not found: %w", err)
+	}
+
+	if entry.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than zero")
+	}
+	if entry.UnitCost < 0 {
+		return nil, fmt.Errorf("unit cost cannot be negative")
+	}
+
+	locationID, err := s.resolveLocation(entry.LocationID)
 	if err != nil {
-		return fmt.Errorf("product not found: %w", err)
+		return nil, err
 	}
 
-	if amount <= 0 {
-		return fmt.Errorf("amount must be greater than zero")
+	// Normalize according to unit, then convert to the base unit
+	amount, factor, err := product.entryQuantity(entry.Amount, entry.Unit)
+	if err != nil {
+		return nil, err
 	}
 
-	// Normalize according to unit
-	amount = NormalizeQuantity(amount, product.Unit)
+	expiry, err := ParseExpiryDate(entry.ExpiryDate)
+	if err != nil {
+		return nil, err
+	}
+	lotNumber := strings.TrimSpace(entry.LotNumber)
+	if product.LotTracking && lotNumber == "" {
+		return nil, fmt.Errorf("%s için parti numarası girilmeli", product.Name)
+	}
 
-	// Increase stock quantity
-	product.StockQuantity += amount
+	// Serial numbers: one new unit each
+	var serials []*SerialNumber
+	if product.SerialTracking {
+		numbers, err := normalizeSerialList(entry.SerialNumbers)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkSerialCount(product, numbers, amount); err != nil {
+			return nil, err
+		}
+		if serials, err = s.prepareReceiveSerials(product, numbers); err != nil {
+			return nil, err
+		}
+		entry.SerialNumbers = numbers
+	}
+
+	// Convert cost to base currency
+	movement := &StockMovement{
+		ID:              uuid.New().String(),
+		ProductID:       product.ID,
+		ProductName:     product.Name,
+		MovementType:    MovementTypeIn,
+		Amount:          amount,
+		Note:            entry.Note,
+		Date:            time.Now(),
+		LocationID:      locationID,
+		BaseUnit:        product.Unit,
+		PurchaseOrderID: entry.PurchaseOrderID,
+	}
+	movement.setEnteredUnit(entry.Unit, factor)
+	if product.LotTracking {
+		movement.LotNumber = lotNumber
+		movement.ExpiryDate = expiry
+		product.addLot(locationID, lotNumber, expiry, amount, movement.Date)
+	}
+	if product.SerialTracking {
+		movement.SerialNumbers = entry.SerialNumbers
+	}
+	if entry.UnitCost > 0 {
+		unitCost, rate, err := s.ConvertToBase(entry.UnitCost, entry.Currency, movement.Date)
+		if err != nil {
+			return nil, err
+		}
+		movement.UnitCost = unitCost.DivQuantity(factor) // Cost is entered per entered unit
+		movement.EnteredCost = entry.UnitCost
+		movement.Currency, _ = normalizeCurrency(entry.Currency) // ConvertToBase doğruladı
+		movement.ExchangeRate = rate
+	}
+
+	// Update costs, then increase stock quantity
+	product.applyStockInCost(amount, movement.UnitCost)
+	product.applyStockDelta(locationID, amount)
 	product.UpdatedAt = time.Now()
 
 	if err := s.SaveProduct(product); err != nil {
-		return fmt.Errorf("stok güncellenemedi: %w", err)
+		return nil, fmt.Errorf("stok güncellenemedi: %w", err)
 	}
 
-	// Create movement record
-	movement := &StockMovement{
-		ID:           uuid.New().String(),
-		ProductID:    productID,
-		ProductName:  product.Name,
-		MovementType: "in",
-		Amount:       amount,
-		Note:         note,
-		Date:         time.Now(),
+	if err := s.SaveStockMovement(movement); err != nil {
+		return nil, err
 	}
 
-	return s.SaveStockMovement(movement)
+	return movement, s.updateSerials(serials, SerialInStock, locationID, SerialEvent{
+		Type:       SerialEventReceived,
+		Date:       movement.Date,
+		MovementID: movement.ID,
+		LocationID: locationID,
+		Note:       movement.Note,
+	})
+}
+
+// applyStockInCost - Update last and weighted average cost for an incoming amount
+// Average = (on hand × average + amount × cost) / (on hand + amount); negative stock counts as zero.
+// A stock-in without cost is valued at the current average and leaves the costs unchanged.
+func (p *Product) applyStockInCost(amount float64, unitCost Money) {
+	if unitCost <= 0 {
+		return
+	}
+
+	p.LastCost = unitCost
+
+	onHand := p.StockQuantity
+	if onHand < 0 || p.AverageCost == 0 {
+		onHand = 0
+	}
+
+	total := p.AverageCost.MulQuantity(onHand) + unitCost.MulQuantity(amount)
+	p.AverageCost = total.DivQuantity(onHand + amount)
 }
 
 // StockOut - Remove stock from product and create movement record
 func (s *BleveStore) StockOut(productID string, amount float64, note string) error {
-	product, err := s.GetProduct(productID)
+	return s.StockOutEntry(BulkStockInfo{ProductID: productID, Amount: amount, Note: note})
+}
+
+// StockOutEntry - Remove stock from a location and create movement record
+func (s *BleveStore) StockOutEntry(entry BulkStockInfo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.stockOutEntry(entry)
+	return err
+}
+
+// stockOutEntry - StockOutEntry gövdesi; yazılan hareketi döner. Çağıran s.mu'yu tutmalıdır
+func (s *BleveStore) stockOutEntry(entry BulkStockInfo) (*StockMovement, error) {
+	product, err := s.GetProduct(entry.ProductID)
 	if err != nil {
-		return fmt.Errorf("product not found: %w", err)
+		return nil, fmt.Errorf("product not found: %w", err)
+	}
+
+	if entry.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than zero")
 	}
 
-	if amount <= 0 {
-		return fmt.Errorf("amount must be greater than zero")
+	locationID, err := s.resolveLocation(entry.LocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Vehicle the parts went into (optional)
+	var vehicle *Vehicle
+	if entry.VehicleID != "" {
+		if vehicle, err = s.GetVehicle(entry.VehicleID); err != nil {
+			return nil, err
+		}
 	}
 
-	// Normalize according to unit
-	amount = NormalizeQuantity(amount, product.Unit)
+	// Normalize according to unit, then convert to the base unit
+	amount, factor, err := product.entryQuantity(entry.Amount, entry.Unit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stock check: quantities reserved for confirmed orders are not available
+	if available := product.AvailableAt(locationID); available < amount {
+		return nil, fmt.Errorf("insufficient stock: available %.2f, requested %.2f", available, amount)
+	}
+
+	// Serial numbers: the units must be in stock at this location
+	var serials []*SerialNumber
+	if product.SerialTracking {
+		numbers, err := normalizeSerialList(entry.SerialNumbers)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkSerialCount(product, numbers, amount); err != nil {
+			return nil, err
+		}
+		if serials, err = s.checkSerialsInStock(product, numbers, locationID, entry.OrderID); err != nil {
+			return nil, err
+		}
+		entry.SerialNumbers = numbers
+	}
 
-	// Stock check
-	if product.StockQuantity < amount {
-		return fmt.Errorf("insufficient stock: available %.2f, requested %.2f", product.StockQuantity, amount)
+	// Lots: chosen lot or FEFO
+	var allocations []LotAllocation
+	if product.LotTracking {
+		allocations, err = product.consumeLots(locationID, amount, entry.LotNumber)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Decrease stock quantity
-	product.StockQuantity -= amount
+	product.applyStockDelta(locationID, -amount)
 	product.UpdatedAt = time.Now()
 
 	if err := s.SaveProduct(product); err != nil {
-		return fmt.Errorf("stok güncellenemedi: %w", err)
+		return nil, fmt.Errorf("stok güncellenemedi: %w", err)
 	}
 
-	// Create movement record
+	// Create movement record (valued at average cost)
 	movement := &StockMovement{
-		ID:           uuid.New().String(),
-		ProductID:    productID,
-		ProductName:  product.Name,
-		MovementType: "out",
-		Amount:       amount,
-		Note:         note,
-		Date:         time.Now(),
+		ID:             uuid.New().String(),
+		ProductID:      product.ID,
+		ProductName:    product.Name,
+		MovementType:   MovementTypeOut,
+		Amount:         amount,
+		Note:           entry.Note,
+		Date:           time.Now(),
+		UnitCost:       product.AverageCost,
+		LocationID:     locationID,
+		LotAllocations: allocations,
+		SerialNumbers:  entry.SerialNumbers,
+		BaseUnit:       product.Unit,
+		OrderID:        entry.OrderID,
+	}
+	if vehicle != nil {
+		movement.VehicleID = vehicle.ID
+		movement.VehiclePlate = vehicle.Plate
+	}
+	movement.setEnteredUnit(entry.Unit, factor)
+
+	if err := s.SaveStockMovement(movement); err != nil {
+		return nil, err
 	}
 
-	return s.SaveStockMovement(movement)
+	return movement, s.updateSerials(serials, SerialOut, "", SerialEvent{
+		Type:       SerialEventIssued,
+		Date:       movement.Date,
+		MovementID: movement.ID,
+		LocationID: locationID,
+		Note:       movement.Note,
+	})
 }
 
 // BulkStockIn - Add stock to multiple products
@@ -1241,7 +2063,7 @@ func (s *BleveStore) BulkStockIn(entries []BulkStockInfo) (int, []string) {
 	var errors []string
 
 	for _, e := range entries {
-		if err := s.StockIn(e.ProductID, e.Amount, e.Note); err != nil {
+		if err := s.StockInEntry(e); err != nil {
 			errors = append(errors, fmt.Sprintf("%s: %v", e.ProductID, err))
 		} else {
 			successful++
@@ -1257,7 +2079,7 @@ func (s *BleveStore) BulkStockOut(entries []BulkStockInfo) (int, []string) {
 	var errors []string
 
 	for _, e := range entries {
-		if err := s.StockOut(e.ProductID, e.Amount, e.Note); err != nil {
+		if err := s.StockOutEntry(e); err != nil {
 			errors = append(errors, fmt.Sprintf("%s: %v", e.ProductID, err))
 		} else {
 			successful++
@@ -1371,7 +2193,10 @@ func (s *BleveStore) GetStockMovements(productID string, start, end time.Time) (
 }
 
 // GetCriticalStockProducts - Get products below critical stock level
-func (s *BleveStore) GetCriticalStockProducts() ([]*Product, error) {
+// With an empty locationID the available stock (on hand - reserved) is checked against
+// CriticalStock; otherwise the stock available at that location is checked against the
+// location's critical level.
+func (s *BleveStore) GetCriticalStockProducts(locationID string) ([]*Product, error) {
 	products, err := s.ListProducts()
 	if err != nil {
 		return nil, err
@@ -1379,11 +2204,18 @@ func (s *BleveStore) GetCriticalStockProducts() ([]*Product, error) {
 
 	var criticals []*Product
 	for _, p := range products {
+		if locationID != "" {
+			if p.AvailableAt(locationID) < float64(p.CriticalStockAt(locationID)) {
+				criticals = append(criticals, p)
+			}
+			continue
+		}
+
 		critical := p.CriticalStock
 		if critical == 0 {
 			critical = 3 // Default
 		}
-		if int(p.StockQuantity) < critical {
+		if int(p.Available()) < critical {
 			criticals = append(criticals, p)
 		}
 	}
@@ -1446,12 +2278,16 @@ func (s *BleveStore) GetBrands() ([]string, error) {
 
 // StockReport - Stock report result
 type StockReport struct {
-	Period           string           `json:"period"`    // "daily" or "monthly"
-	Date             string           `json:"date"`      // "2024-01-15" or "2024-01"
-	TotalIn          float64          `json:"total_in"`  // Total in amount
-	TotalOut         float64          `json:"total_out"` // Total out amount
+	Period           string           `json:"period"`       // "daily" or "monthly"
+	Date             string           `json:"date"`         // "2024-01-15" or "2024-01"
+	TotalIn          float64          `json:"total_in"`     // Total in amount
+	TotalOut         float64          `json:"total_out"`    // Total out amount
+	TotalAdjust      float64          `json:"total_adjust"` // Net adjustment amount
 	InMovementCount  int              `json:"in_movement_count"`
 	OutMovementCount int              `json:"out_movement_count"`
+	AdjustCount      int              `json:"adjust_count"`
+	TransferCount    int              `json:"transfer_count"`
+	ReversedCount    int              `json:"reversed_count"` // Reversed pairs left out of the report
 	MostUsedItems    []ProductUsage   `json:"most_used_items"`
 	Movements        []*StockMovement `json:"movements"`
 }
@@ -1466,25 +2302,31 @@ type ProductUsage struct {
 
 // GetStockReport - Generate daily or monthly stock report
 func (s *BleveStore) GetStockReport(period string, date time.Time) (*StockReport, error) {
-	var start, end time.Time
-
-	if period == "daily" {
-		start = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-		end = start.Add(24 * time.Hour)
-	} else {
-		// Monthly
-		start = time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
-		end = start.AddDate(0, 1, 0)
-	}
+	start, end := reportPeriodRange(period, date)
 
 	movements, err := s.GetStockMovements("", start, end)
 	if err != nil {
 		return nil, err
 	}
 
+	// Reversed movements and their counter-movements cancel out; leave both out
+	var effective []*StockMovement
+	reversed := 0
+	for _, m := range movements {
+		if m.IsReversed() || m.ReversalOf != "" {
+			if m.IsReversed() {
+				reversed++
+			}
+			continue
+		}
+		effective = append(effective, m)
+	}
+	movements = effective
+
 	report := &StockReport{
-		Period:    period,
-		Movements: movements,
+		Period:        period,
+		Movements:     movements,
+		ReversedCount: reversed,
 	}
 
 	if period == "daily" {
@@ -1497,10 +2339,16 @@ func (s *BleveStore) GetStockReport(period string, date time.Time) (*StockReport
 	productOutMap := make(map[string]*ProductUsage)
 
 	for _, m := range movements {
-		if m.MovementType == "in" {
+		switch m.MovementType {
+		case MovementTypeIn:
 			report.TotalIn += m.Amount
 			report.InMovementCount++
-		} else {
+		case MovementTypeAdjust:
+			report.TotalAdjust += m.Amount
+			report.AdjustCount++
+		case MovementTypeTransfer:
+			report.TransferCount++
+		default:
 			report.TotalOut += m.Amount
 			report.OutMovementCount++
 
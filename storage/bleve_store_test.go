@@ -0,0 +1,134 @@
+package storage
+
+import "testing"
+
+// newTestStore - Geçici veri dizininde boş bir depo açar
+func newTestStore(t *testing.T) *BleveStore {
+	t.Helper()
+	t.Setenv("APPDATA", t.TempDir())
+
+	s, err := NewBleveStore()
+	if err != nil {
+		t.Fatalf("NewBleveStore: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestCalculateGrandTotal(t *testing.T) {
+	taxRate := func(rate float64) *float64 { return &rate }
+	item := func(quantity float64, price Money, rate float64, discountType string, discountValue float64) OrderItem {
+		return OrderItem{
+			Quantity:      quantity,
+			UnitPrice:     price,
+			TaxRate:       taxRate(rate),
+			DiscountType:  discountType,
+			DiscountValue: discountValue,
+		}
+	}
+
+	tests := []struct {
+		name          string
+		order         Order
+		wantShares    []Money
+		wantNet       []Money
+		wantTax       []Money
+		wantDiscount  Money
+		wantSubtotal  Money
+		wantTaxTotal  Money
+		wantGrand     Money
+		wantBreakdown []TaxBreakdown
+	}{
+		{
+			name: "amount discount spread with remainder on last item",
+			order: Order{
+				DiscountType:  DiscountTypeAmount,
+				DiscountValue: 10,
+				Items:         []OrderItem{item(3, 3333, 20, "", 0), item(1, 10000, 10, "", 0)},
+			},
+			wantShares:    []Money{500, 500},
+			wantNet:       []Money{9499, 9500},
+			wantTax:       []Money{1900, 950},
+			wantDiscount:  1000,
+			wantSubtotal:  18999,
+			wantTaxTotal:  2850,
+			wantGrand:     21849,
+			wantBreakdown: []TaxBreakdown{{Rate: 10, Base: 9500, Tax: 950}, {Rate: 20, Base: 9499, Tax: 1900}},
+		},
+		{
+			name: "item and order percent discounts on tax-inclusive prices",
+			order: Order{
+				PricesIncludeTax: true,
+				DiscountType:     DiscountTypePercent,
+				DiscountValue:    5,
+				Items:            []OrderItem{item(2, 6000, 20, DiscountTypePercent, 10), item(1, 5000, 20, "", 0)},
+			},
+			wantShares:    []Money{540, 250},
+			wantNet:       []Money{8550, 3958},
+			wantTax:       []Money{1710, 792},
+			wantDiscount:  1990,
+			wantSubtotal:  12508,
+			wantTaxTotal:  2502,
+			wantGrand:     15010,
+			wantBreakdown: []TaxBreakdown{{Rate: 20, Base: 12508, Tax: 2502}},
+		},
+		{
+			name: "order discount capped at the line total",
+			order: Order{
+				DiscountType:  DiscountTypeAmount,
+				DiscountValue: 50,
+				Items:         []OrderItem{item(1, 1000, 20, "", 0)},
+			},
+			wantShares:    []Money{1000},
+			wantNet:       []Money{0},
+			wantTax:       []Money{0},
+			wantDiscount:  1000,
+			wantBreakdown: []TaxBreakdown{{Rate: 20}},
+		},
+		{
+			name: "free items take no share",
+			order: Order{
+				DiscountType:  DiscountTypeAmount,
+				DiscountValue: 1,
+				Items:         []OrderItem{item(1, 1000, 20, "", 0), item(1, 0, 20, "", 0)},
+			},
+			wantShares:    []Money{100, 0},
+			wantNet:       []Money{900, 0},
+			wantTax:       []Money{180, 0},
+			wantDiscount:  100,
+			wantSubtotal:  900,
+			wantTaxTotal:  180,
+			wantGrand:     1080,
+			wantBreakdown: []TaxBreakdown{{Rate: 20, Base: 900, Tax: 180}},
+		},
+	}
+
+	for _, tt := range tests {
+		order := tt.order
+		order.CalculateGrandTotal()
+
+		for i := range order.Items {
+			got := &order.Items[i]
+			if got.OrderDiscountShare != tt.wantShares[i] || got.NetAmount != tt.wantNet[i] || got.TaxAmount != tt.wantTax[i] {
+				t.Errorf("%s: item %d share/net/tax = %d/%d/%d, want %d/%d/%d", tt.name, i,
+					got.OrderDiscountShare, got.NetAmount, got.TaxAmount, tt.wantShares[i], tt.wantNet[i], tt.wantTax[i])
+			}
+		}
+		if order.DiscountTotal != tt.wantDiscount || order.Subtotal != tt.wantSubtotal ||
+			order.TaxTotal != tt.wantTaxTotal || order.GrandTotal != tt.wantGrand {
+			t.Errorf("%s: discount/subtotal/tax/grand = %d/%d/%d/%d, want %d/%d/%d/%d", tt.name,
+				order.DiscountTotal, order.Subtotal, order.TaxTotal, order.GrandTotal,
+				tt.wantDiscount, tt.wantSubtotal, tt.wantTaxTotal, tt.wantGrand)
+		}
+		if len(order.TaxBreakdown) != len(tt.wantBreakdown) {
+			t.Errorf("%s: tax breakdown %+v, want %+v", tt.name, order.TaxBreakdown, tt.wantBreakdown)
+			continue
+		}
+		for i, b := range order.TaxBreakdown {
+			if b != tt.wantBreakdown[i] {
+				t.Errorf("%s: tax breakdown %+v, want %+v", tt.name, order.TaxBreakdown, tt.wantBreakdown)
+				break
+			}
+		}
+	}
+}
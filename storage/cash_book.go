@@ -0,0 +1,535 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ============================================
+// Kasa Defteri
+// Kasaya nakit girişleri nakit ödemelerden (Payment, method = cash) ve elle
+// girilen kasa hareketlerinden gelir; çıkışlar nakit ödenen masraflar (Expense) ve
+// masraf dışı kasa çıkışlarıdır (bankaya yatırma, ortak çekişi vb.).
+// Günün açılış bakiyesi elle girilmediyse önceki günlerden devreder: en son
+// sayılan (kapatılan) günün sayım tutarına sonraki günlerin net hareketi eklenir.
+// Gün kapatılırken sayılan tutar beklenen bakiyeyle karşılaştırılır; kapatılan
+// güne nakit hareketi girilemez (önce gün yeniden açılmalıdır).
+// Tüm tutarlar ana para birimindedir.
+// ============================================
+
+const (
+	cashDaysDir    = "cash_days"
+	cashEntriesDir = "cash_entries"
+)
+
+// Cash entry types
+const (
+	CashEntryIn  = "in"  // Kasaya giriş (ödeme dışı)
+	CashEntryOut = "out" // Kasadan çıkış (masraf dışı; masraflar Expense olarak girilir)
+)
+
+// CashCategories - Suggested categories of manual cash entries (free text is also accepted)
+var CashCategories = []string{"Bankaya yatırılan", "Bankadan çekilen", "Ortak çekişi", "Ortak katkısı", "Diğer"}
+
+// CashEntry - Manual cash in / out of the till
+type CashEntry struct {
+	ID          string    `json:"id"`
+	Date        time.Time `json:"date"`
+	Type        string    `json:"type"`     // in, out
+	Category    string    `json:"category"` // Çıkışlarda zorunlu
+	Amount      Money     `json:"amount"`   // Ana para birimi
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// CashDay - Opening balance and closing count of a day
+type CashDay struct {
+	Date            string     `json:"date"` // "2006-01-02"
+	OpeningBalance  Money      `json:"opening_balance"`
+	OpeningSet      bool       `json:"opening_set"` // Açılış elle girildi (devretmez)
+	Closed          bool       `json:"closed"`
+	CountedBalance  Money      `json:"counted_balance"`  // Kapanışta sayılan
+	ExpectedBalance Money      `json:"expected_balance"` // Kapanıştaki beklenen bakiye
+	Difference      Money      `json:"difference"`       // Sayılan - beklenen (eksi = kasa açığı)
+	ClosedAt        *time.Time `json:"closed_at,omitempty"`
+	Note            string     `json:"note"`
+}
+
+// CashCategoryTotal - Total cash out of a category
+type CashCategoryTotal struct {
+	Category string `json:"category"`
+	Amount   Money  `json:"amount"`
+	Count    int    `json:"count"`
+}
+
+// PaymentMethodTotal - Payments of a day by method
+type PaymentMethodTotal struct {
+	Method string `json:"method"`
+	Name   string `json:"name"`
+	Amount Money  `json:"amount"`
+	Count  int    `json:"count"`
+}
+
+// CashReport - Daily cash book summary (Z report)
+type CashReport struct {
+	Date            string                `json:"date"`
+	Currency        string                `json:"currency"`
+	OpeningBalance  Money                 `json:"opening_balance"`
+	OpeningSet      bool                  `json:"opening_set"`
+	Payments        []*Payment            `json:"payments"`         // All payments of the day
+	PaymentsByType  []*PaymentMethodTotal `json:"payments_by_type"` // Nakit, kart, havale
+	PaymentsTotal   Money                 `json:"payments_total"`
+	CashPayments    Money                 `json:"cash_payments"` // Nakit ödemeler (kasaya girer)
+	Entries         []*CashEntry          `json:"entries"`
+	Expenses        []*Expense            `json:"expenses"` // Nakit masraflar
+	OtherCashIn     Money                 `json:"other_cash_in"`
+	CashOut         Money                 `json:"cash_out"`
+	OutByCategory   []*CashCategoryTotal  `json:"out_by_category"`
+	ExpectedBalance Money                 `json:"expected_balance"` // Açılış + girişler - çıkışlar
+	Closed          bool                  `json:"closed"`
+	CountedBalance  Money                 `json:"counted_balance"`
+	Difference      Money                 `json:"difference"`
+	ClosedAt        *time.Time            `json:"closed_at,omitempty"`
+	Note            string                `json:"note"`
+}
+
+// cashDayKey - Tarihin kasa günü anahtarı (yerel saat)
+func cashDayKey(t time.Time) string {
+	return t.In(time.Local).Format("2006-01-02")
+}
+
+// cashDayRange - Kasa gününün başlangıcı ve bitişi (bitiş dahil)
+func cashDayRange(key string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation("2006-01-02", key, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("geçersiz tarih: %q", key)
+	}
+	return start, start.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+}
+
+// getCashDay - Günün kaydı (yoksa boş kayıt)
+func (s *BleveStore) getCashDay(key string) *CashDay {
+	var day CashDay
+	if err := s.readJSONFile(cashDaysDir, key, &day); err != nil {
+		return &CashDay{Date: key}
+	}
+	return &day
+}
+
+// checkCashDayOpen - Gün kapatıldıysa hata döner
+func (s *BleveStore) checkCashDayOpen(t time.Time) error {
+	key := cashDayKey(t)
+	if s.getCashDay(key).Closed {
+		return fmt.Errorf("%s kasa günü kapatılmış; önce günü yeniden açın", key)
+	}
+	return nil
+}
+
+// cashFlow - [start, end] aralığındaki net nakit hareketi (nakit ödemeler + girişler - çıkışlar - nakit masraflar)
+func (s *BleveStore) cashFlow(start, end time.Time) (Money, error) {
+	payments, err := s.ListPayments("", start, end)
+	if err != nil {
+		return 0, err
+	}
+	entries, err := s.ListCashEntries(start, end)
+	if err != nil {
+		return 0, err
+	}
+	expenses, err := s.cashExpenses(start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	total := Money(0)
+	for _, payment := range payments {
+		if payment.Method == PaymentMethodCash {
+			total += payment.BaseAmount
+		}
+	}
+	for _, entry := range entries {
+		if entry.Type == CashEntryIn {
+			total += entry.Amount
+		} else {
+			total -= entry.Amount
+		}
+	}
+	for _, expense := range expenses {
+		total -= expense.BaseAmount
+	}
+	return total, nil
+}
+
+// cashOpeningBalance - Günün açılış bakiyesi
+// Elle girilmediyse önceki son sayım (veya elle girilen açılış) ile aradaki net hareketten hesaplanır.
+func (s *BleveStore) cashOpeningBalance(key string) (Money, error) {
+	day := s.getCashDay(key)
+	if day.OpeningSet {
+		return day.OpeningBalance, nil
+	}
+
+	dayStart, _, err := cashDayRange(key)
+	if err != nil {
+		return 0, err
+	}
+
+	ids, err := s.listJSONFileIDs(cashDaysDir)
+	if err != nil {
+		return 0, err
+	}
+	sort.Strings(ids)
+
+	// Bugünden önceki en son dayanak gün
+	var anchor *CashDay
+	for i := len(ids) - 1; i >= 0; i-- {
+		if ids[i] >= key {
+			continue
+		}
+		candidate := s.getCashDay(ids[i])
+		if candidate.Closed || candidate.OpeningSet {
+			anchor = candidate
+			break
+		}
+	}
+
+	balance := Money(0)
+	from := time.Time{}
+	if anchor != nil {
+		anchorStart, anchorEnd, err := cashDayRange(anchor.Date)
+		if err != nil {
+			return 0, err
+		}
+		if anchor.Closed {
+			balance = anchor.CountedBalance
+			from = anchorEnd.Add(time.Nanosecond)
+		} else {
+			balance = anchor.OpeningBalance
+			from = anchorStart
+		}
+	}
+
+	flow, err := s.cashFlow(from, dayStart.Add(-time.Nanosecond))
+	if err != nil {
+		return 0, err
+	}
+	return balance + flow, nil
+}
+
+// prepareCashEntry - Kasa hareketini doğrular
+func (s *BleveStore) prepareCashEntry(entry *CashEntry) error {
+	if entry.Type != CashEntryIn && entry.Type != CashEntryOut {
+		return fmt.Errorf("geçersiz kasa hareketi türü: %q", entry.Type)
+	}
+	if entry.Amount <= 0 {
+		return fmt.Errorf("tutar sıfırdan büyük olmalı")
+	}
+	entry.Category = strings.TrimSpace(entry.Category)
+	entry.Description = strings.TrimSpace(entry.Description)
+	if entry.Type == CashEntryOut && entry.Category == "" {
+		return fmt.Errorf("kasa çıkışı için kategori gerekli")
+	}
+	if entry.Date.IsZero() {
+		entry.Date = time.Now()
+	}
+	return s.checkCashDayOpen(entry.Date)
+}
+
+// CreateCashEntry - Records a manual cash in or out
+func (s *BleveStore) CreateCashEntry(entry *CashEntry) (*CashEntry, error) {
+	if err := s.prepareCashEntry(entry); err != nil {
+		return nil, err
+	}
+
+	entry.ID = uuid.New().String()
+	entry.CreatedAt = time.Now()
+	entry.UpdatedAt = entry.CreatedAt
+
+	if err := s.writeJSONFile(cashEntriesDir, entry.ID, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// UpdateCashEntry - Updates a manual cash entry (both the old and new day must be open)
+func (s *BleveStore) UpdateCashEntry(entry *CashEntry) (*CashEntry, error) {
+	existing, err := s.GetCashEntry(entry.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkCashDayOpen(existing.Date); err != nil {
+		return nil, err
+	}
+	if err := s.prepareCashEntry(entry); err != nil {
+		return nil, err
+	}
+
+	entry.CreatedAt = existing.CreatedAt
+	entry.UpdatedAt = time.Now()
+
+	if err := s.writeJSONFile(cashEntriesDir, entry.ID, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// DeleteCashEntry - Deletes a manual cash entry of an open day
+func (s *BleveStore) DeleteCashEntry(id string) error {
+	entry, err := s.GetCashEntry(id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkCashDayOpen(entry.Date); err != nil {
+		return err
+	}
+	return s.removeJSONFile(cashEntriesDir, id)
+}
+
+// GetCashEntry - Returns a cash entry by ID
+func (s *BleveStore) GetCashEntry(id string) (*CashEntry, error) {
+	var entry CashEntry
+	if err := s.readJSONFile(cashEntriesDir, id, &entry); err != nil {
+		return nil, fmt.Errorf("kasa hareketi bulunamadı: %s", id)
+	}
+	return &entry, nil
+}
+
+// ListCashEntries - Cash entries between the dates (zero = unbounded), oldest first
+func (s *BleveStore) ListCashEntries(start, end time.Time) ([]*CashEntry, error) {
+	ids, err := s.listJSONFileIDs(cashEntriesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*CashEntry{}
+	for _, id := range ids {
+		var entry CashEntry
+		if err := s.readJSONFile(cashEntriesDir, id, &entry); err != nil {
+			continue
+		}
+		if !start.IsZero() && entry.Date.Before(start) {
+			continue
+		}
+		if !end.IsZero() && entry.Date.After(end) {
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+
+	return entries, nil
+}
+
+// SetCashOpeningBalance - Sets a day's opening balance by hand (overrides the carried balance)
+func (s *BleveStore) SetCashOpeningBalance(date time.Time, amount Money) error {
+	key := cashDayKey(date)
+	day := s.getCashDay(key)
+	if day.Closed {
+		return fmt.Errorf("%s kasa günü kapatılmış; önce günü yeniden açın", key)
+	}
+
+	day.OpeningBalance = amount
+	day.OpeningSet = true
+	return s.writeJSONFile(cashDaysDir, key, day)
+}
+
+// CloseCashDay - Records the closing count of a day and the difference to the expected balance
+func (s *BleveStore) CloseCashDay(date time.Time, counted Money, note string) (*CashReport, error) {
+	key := cashDayKey(date)
+	day := s.getCashDay(key)
+	if day.Closed {
+		return nil, fmt.Errorf("%s kasa günü zaten kapatılmış", key)
+	}
+
+	report, err := s.GetCashReport(date)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	day.Closed = true
+	day.CountedBalance = counted
+	day.ExpectedBalance = report.ExpectedBalance
+	day.Difference = counted - report.ExpectedBalance
+	day.ClosedAt = &now
+	day.Note = strings.TrimSpace(note)
+	if err := s.writeJSONFile(cashDaysDir, key, day); err != nil {
+		return nil, err
+	}
+
+	return s.GetCashReport(date)
+}
+
+// ReopenCashDay - Reopens a closed day so its cash records can be changed again
+func (s *BleveStore) ReopenCashDay(date time.Time) error {
+	key := cashDayKey(date)
+	day := s.getCashDay(key)
+	if !day.Closed {
+		return nil
+	}
+
+	day.Closed = false
+	day.CountedBalance = 0
+	day.ExpectedBalance = 0
+	day.Difference = 0
+	day.ClosedAt = nil
+	return s.writeJSONFile(cashDaysDir, key, day)
+}
+
+// GetCashReport - Daily cash book summary built from the payments, cash expenses and cash entries of the day
+func (s *BleveStore) GetCashReport(date time.Time) (*CashReport, error) {
+	if date.IsZero() {
+		date = time.Now()
+	}
+	key := cashDayKey(date)
+	start, end, err := cashDayRange(key)
+	if err != nil {
+		return nil, err
+	}
+
+	day := s.getCashDay(key)
+	opening, err := s.cashOpeningBalance(key)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.ListPayments("", start, end)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.ListCashEntries(start, end)
+	if err != nil {
+		return nil, err
+	}
+	expenses, err := s.cashExpenses(start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CashReport{
+		Date:           key,
+		Currency:       GetBaseCurrency(),
+		OpeningBalance: opening,
+		OpeningSet:     day.OpeningSet,
+		Payments:       payments,
+		Entries:        entries,
+		Expenses:       expenses,
+		Closed:         day.Closed,
+		CountedBalance: day.CountedBalance,
+		Difference:     day.Difference,
+		ClosedAt:       day.ClosedAt,
+		Note:           day.Note,
+	}
+
+	// Ödemeler yönteme göre
+	byMethod := make(map[string]*PaymentMethodTotal)
+	for _, method := range []string{PaymentMethodCash, PaymentMethodCard, PaymentMethodTransfer} {
+		total := &PaymentMethodTotal{Method: method, Name: paymentMethodName(method)}
+		byMethod[method] = total
+		report.PaymentsByType = append(report.PaymentsByType, total)
+	}
+	for _, payment := range payments {
+		total := byMethod[payment.Method]
+		if total == nil {
+			continue
+		}
+		total.Amount += payment.BaseAmount
+		total.Count++
+		report.PaymentsTotal += payment.BaseAmount
+	}
+	report.CashPayments = byMethod[PaymentMethodCash].Amount
+
+	// Nakit masraflar ve kasa hareketleri; çıkışlar kategoriye göre
+	byCategory := make(map[string]*CashCategoryTotal)
+	cashOut := func(category string, amount Money) {
+		report.CashOut += amount
+		total := byCategory[category]
+		if total == nil {
+			total = &CashCategoryTotal{Category: category}
+			byCategory[category] = total
+			report.OutByCategory = append(report.OutByCategory, total)
+		}
+		total.Amount += amount
+		total.Count++
+	}
+	for _, expense := range expenses {
+		cashOut(expense.Category, expense.BaseAmount)
+	}
+	for _, entry := range entries {
+		if entry.Type == CashEntryIn {
+			report.OtherCashIn += entry.Amount
+			continue
+		}
+		cashOut(entry.Category, entry.Amount)
+	}
+	sort.Slice(report.OutByCategory, func(i, j int) bool {
+		return report.OutByCategory[i].Amount > report.OutByCategory[j].Amount
+	})
+
+	report.ExpectedBalance = opening + report.CashPayments + report.OtherCashIn - report.CashOut
+
+	return report, nil
+}
+
+// FormatCashReport - Printable text of a daily cash report (Z report)
+func FormatCashReport(report *CashReport) string {
+	const width = 40
+	var b strings.Builder
+
+	line := func(label string, amount Money) {
+		value := amount.String() + " " + report.Currency
+		pad := width - len([]rune(label)) - len([]rune(value))
+		if pad < 1 {
+			pad = 1
+		}
+		fmt.Fprintf(&b, "%s%s%s\n", label, strings.Repeat(" ", pad), value)
+	}
+	rule := func() {
+		b.WriteString(strings.Repeat("-", width) + "\n")
+	}
+
+	b.WriteString("KASA RAPORU (Z)\n")
+	fmt.Fprintf(&b, "Tarih: %s\n", report.Date)
+	if report.Closed && report.ClosedAt != nil {
+		fmt.Fprintf(&b, "Kapanış: %s\n", report.ClosedAt.In(time.Local).Format("2006-01-02 15:04"))
+	} else {
+		b.WriteString("Durum: AÇIK (sayım yapılmadı)\n")
+	}
+	rule()
+
+	line("Açılış bakiyesi", report.OpeningBalance)
+	rule()
+
+	fmt.Fprintf(&b, "TAHSİLATLAR (%d)\n", len(report.Payments))
+	for _, total := range report.PaymentsByType {
+		line(fmt.Sprintf("  %s (%d)", total.Name, total.Count), total.Amount)
+	}
+	line("  Toplam", report.PaymentsTotal)
+	rule()
+
+	b.WriteString("KASA HAREKETLERİ\n")
+	line("  Nakit tahsilat", report.CashPayments)
+	line("  Diğer girişler", report.OtherCashIn)
+	for _, total := range report.OutByCategory {
+		line(fmt.Sprintf("  %s (%d)", total.Category, total.Count), -total.Amount)
+	}
+	line("  Toplam çıkış", -report.CashOut)
+	rule()
+
+	line("Beklenen bakiye", report.ExpectedBalance)
+	if report.Closed {
+		line("Sayılan", report.CountedBalance)
+		line("Fark", report.Difference)
+		if report.Note != "" {
+			fmt.Fprintf(&b, "Not: %s\n", report.Note)
+		}
+	}
+
+	return b.String()
+}
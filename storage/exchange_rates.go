@@ -0,0 +1,364 @@
+package storage
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ============================================
+// Döviz Kurları
+// Kurlar yerel olarak saklanır; elle girilir veya dosyadan içe aktarılır.
+// Rate: 1 birim döviz = Rate ana para birimi (örn: 1 EUR = 35.12 TRY)
+// ============================================
+
+// Currency codes
+const (
+	CurrencyTRY = "TRY"
+	CurrencyEUR = "EUR"
+	CurrencyUSD = "USD"
+)
+
+// Exchange rate sources
+const (
+	RateSourceManual = "manual"
+	RateSourceImport = "import"
+)
+
+const exchangeRatesDir = "exchange_rates"
+
+// ExchangeRate - Tarihli döviz kuru
+type ExchangeRate struct {
+	ID        string    `json:"id"`       // "EUR_2024-01-15"
+	Currency  string    `json:"currency"` // ISO 4217 kodu
+	Date      time.Time `json:"date"`     // Kurun geçerli olduğu gün
+	Rate      float64   `json:"rate"`     // 1 birim döviz karşılığı ana para birimi
+	Source    string    `json:"source"`   // "manual" veya "import"
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GetCurrencies - Returns selectable currencies, base currency first
+func GetCurrencies() []string {
+	base := GetBaseCurrency()
+	currencies := []string{base}
+	for _, c := range []string{CurrencyTRY, CurrencyEUR, CurrencyUSD} {
+		if c != base {
+			currencies = append(currencies, c)
+		}
+	}
+	return currencies
+}
+
+// currencyCodePattern - ISO 4217 biçimi; kod dosya adına girdiği için başka karakter kabul edilmez
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// normalizeCurrency - Para birimi kodunu büyük harfe çevirip doğrular; boşsa ana para birimi
+func normalizeCurrency(currency string) (string, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return GetBaseCurrency(), nil
+	}
+	if !currencyCodePattern.MatchString(currency) {
+		return "", fmt.Errorf("geçersiz para birimi: %q", currency)
+	}
+	return currency, nil
+}
+
+// UpdateBaseCurrency - Ana para birimini değiştirir
+// Siparişler, stok hareketleri, ödemeler, masraflar, kasa hareketleri, satın alma
+// siparişleri ve kurlar önceki ana para birimine göre kayıtlıdır; bunlardan biri
+// varsa değişiklik reddedilir (eski kayıtlar yeni para birimine göre okunurdu).
+func (s *BleveStore) UpdateBaseCurrency(currency string) error {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if !currencyCodePattern.MatchString(currency) {
+		return fmt.Errorf("geçersiz para birimi: %q", currency)
+	}
+	if currency == GetBaseCurrency() {
+		return nil
+	}
+
+	records := []struct{ dir, name string }{
+		{"orders", "sipariş"},
+		{"stock_movements", "stok hareketi"},
+		{paymentsDir, "ödeme"},
+		{expensesDir, "masraf"},
+		{cashEntriesDir, "kasa hareketi"},
+		{purchaseOrdersDir, "satın alma siparişi"},
+		{exchangeRatesDir, "döviz kuru"},
+	}
+	for _, r := range records {
+		ids, err := s.listJSONFileIDs(r.dir)
+		if err != nil {
+			return err
+		}
+		if len(ids) > 0 {
+			return fmt.Errorf("kayıtlı %s varken ana para birimi değiştirilemez", r.name)
+		}
+	}
+
+	return saveBaseCurrency(currency)
+}
+
+// exchangeRateID - Aynı gün ve para birimi için tek kayıt
+func exchangeRateID(currency string, date time.Time) string {
+	return currency + "_" + date.Format("2006-01-02")
+}
+
+// SaveExchangeRate - Kuru kaydeder; aynı gün için önceki kuru değiştirir
+func (s *BleveStore) SaveExchangeRate(currency string, date time.Time, rate float64, source string) (*ExchangeRate, error) {
+	currency, err := normalizeCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+	if currency == GetBaseCurrency() {
+		return nil, fmt.Errorf("ana para birimi (%s) için kur girilemez", currency)
+	}
+	if rate <= 0 {
+		return nil, fmt.Errorf("kur sıfırdan büyük olmalı")
+	}
+	if date.IsZero() {
+		date = time.Now()
+	}
+	if source == "" {
+		source = RateSourceManual
+	}
+
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.Local)
+	exchangeRate := &ExchangeRate{
+		ID:        exchangeRateID(currency, day),
+		Currency:  currency,
+		Date:      day,
+		Rate:      rate,
+		Source:    source,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.writeJSONFile(exchangeRatesDir, exchangeRate.ID, exchangeRate); err != nil {
+		return nil, err
+	}
+
+	return exchangeRate, nil
+}
+
+// DeleteExchangeRate - Kuru siler
+func (s *BleveStore) DeleteExchangeRate(id string) error {
+	return s.removeJSONFile(exchangeRatesDir, id)
+}
+
+// ListExchangeRates - Kurları listeler (currency boşsa tümü), yeniden eskiye
+func (s *BleveStore) ListExchangeRates(currency string) ([]*ExchangeRate, error) {
+	ids, err := s.listJSONFileIDs(exchangeRatesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+
+	rates := []*ExchangeRate{}
+	for _, id := range ids {
+		var rate ExchangeRate
+		if err := s.readJSONFile(exchangeRatesDir, id, &rate); err != nil {
+			continue
+		}
+		if currency != "" && rate.Currency != currency {
+			continue
+		}
+		rates = append(rates, &rate)
+	}
+
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Date.Equal(rates[j].Date) {
+			return rates[i].Currency < rates[j].Currency
+		}
+		return rates[i].Date.After(rates[j].Date)
+	})
+
+	return rates, nil
+}
+
+// exchangeRateTable - Para birimine göre kurlar (yeniden eskiye); raporlarda tekrar tekrar
+// dosya okumamak için bir kez yüklenir
+type exchangeRateTable map[string][]*ExchangeRate
+
+// loadExchangeRateTable - Tüm kurları bellekte tabloya yükler
+func (s *BleveStore) loadExchangeRateTable() (exchangeRateTable, error) {
+	rates, err := s.ListExchangeRates("")
+	if err != nil {
+		return nil, err
+	}
+
+	table := make(exchangeRateTable)
+	for _, r := range rates {
+		table[r.Currency] = append(table[r.Currency], r)
+	}
+	return table, nil
+}
+
+// rate - Verilen gün için geçerli kur (o gün veya öncesindeki en son kur)
+func (t exchangeRateTable) rate(currency string, date time.Time) (float64, error) {
+	currency, err := normalizeCurrency(currency)
+	if err != nil {
+		return 0, err
+	}
+	if currency == GetBaseCurrency() {
+		return 1, nil
+	}
+
+	endOfDay := time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 0, time.Local)
+	for _, r := range t[currency] {
+		if !r.Date.After(endOfDay) {
+			return r.Rate, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%s için %s tarihinde geçerli kur yok", currency, date.Format("2006-01-02"))
+}
+
+// orderRate - Sipariş tarihindeki kur; kur bulunamazsa siparişte kayıtlı kur
+func (t exchangeRateTable) orderRate(order *Order) (float64, error) {
+	rate, err := t.rate(order.Currency, order.CreatedAt)
+	if err == nil {
+		return rate, nil
+	}
+	if order.ExchangeRate > 0 {
+		return order.ExchangeRate, nil
+	}
+	return 0, err
+}
+
+// GetExchangeRate - Verilen gün için geçerli kuru döner (o gün veya öncesindeki en son kur)
+// Ana para birimi için her zaman 1 döner.
+func (s *BleveStore) GetExchangeRate(currency string, date time.Time) (float64, error) {
+	currency, err := normalizeCurrency(currency)
+	if err != nil {
+		return 0, err
+	}
+	if currency == GetBaseCurrency() {
+		return 1, nil
+	}
+
+	table, err := s.loadExchangeRateTable()
+	if err != nil {
+		return 0, err
+	}
+	return table.rate(currency, date)
+}
+
+// ConvertToBase - Tutarı verilen günün kuruyla ana para birimine çevirir
+func (s *BleveStore) ConvertToBase(amount Money, currency string, date time.Time) (Money, float64, error) {
+	rate, err := s.GetExchangeRate(currency, date)
+	if err != nil {
+		return 0, 0, err
+	}
+	return amount.MulRate(rate), rate, nil
+}
+
+// ImportExchangeRates - Kurları metin dosyasından içe aktarır
+// Her satır: tarih;para birimi;kur (örn: "2024-01-15;EUR;35,12"). Ayırıcı ";" , "," veya
+// sekme olabilir; "#" ile başlayan satırlar ve başlık satırı atlanır.
+func (s *BleveStore) ImportExchangeRates(r io.Reader) (int, []string) {
+	imported := 0
+	var errors []string
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var fields []string
+		switch {
+		case strings.Contains(line, ";"):
+			fields = strings.Split(line, ";")
+		case strings.Contains(line, "\t"):
+			fields = strings.Split(line, "\t")
+		default:
+			fields = strings.Split(line, ",")
+		}
+
+		// "2024-01-15,EUR,35,12" gibi ondalık virgüllü satırlar
+		if len(fields) == 4 {
+			fields = []string{fields[0], fields[1], fields[2] + "." + fields[3]}
+		}
+		if len(fields) != 3 {
+			errors = append(errors, fmt.Sprintf("satır %d: beklenen 'tarih;para birimi;kur'", lineNo))
+			continue
+		}
+
+		date, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(fields[0]), time.Local)
+		if err != nil {
+			if lineNo == 1 {
+				continue // Başlık satırı
+			}
+			errors = append(errors, fmt.Sprintf("satır %d: geçersiz tarih %q", lineNo, fields[0]))
+			continue
+		}
+
+		rateStr := strings.Replace(strings.TrimSpace(fields[2]), ",", ".", 1)
+		rate, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("satır %d: geçersiz kur %q", lineNo, fields[2]))
+			continue
+		}
+
+		if _, err := s.SaveExchangeRate(fields[1], date, rate, RateSourceImport); err != nil {
+			errors = append(errors, fmt.Sprintf("satır %d: %v", lineNo, err))
+			continue
+		}
+		imported++
+	}
+
+	if err := scanner.Err(); err != nil {
+		errors = append(errors, err.Error())
+	}
+
+	return imported, errors
+}
+
+// applyExchangeRate - Siparişin kurunu sipariş tarihine göre belirler ve ana para birimi toplamlarını hesaplar
+func (s *BleveStore) applyExchangeRate(order *Order) error {
+	currency, err := normalizeCurrency(order.Currency)
+	if err != nil {
+		return err
+	}
+	order.Currency = currency
+
+	rate, err := s.GetExchangeRate(order.Currency, order.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	order.ExchangeRate = rate
+	order.calculateBaseTotals()
+	return nil
+}
+
+// calculateBaseTotals - Siparişte kayıtlı kurla ana para birimi toplamlarını hesaplar
+// Kur kaydı olmayan eski siparişler ana para biriminde kabul edilir.
+func (order *Order) calculateBaseTotals() {
+	if order.Currency == "" {
+		order.Currency = GetBaseCurrency()
+	}
+	if order.ExchangeRate == 0 && order.Currency == GetBaseCurrency() {
+		order.ExchangeRate = 1
+	}
+
+	order.BaseSubtotal = order.Subtotal.MulRate(order.ExchangeRate)
+	order.BaseGrandTotal = order.GrandTotal.MulRate(order.ExchangeRate)
+
+	// Maliyet ve kâr (maliyetler zaten ana para biriminde)
+	order.CostTotal = 0
+	for _, item := range order.Items {
+		order.CostTotal += item.UnitCost.MulQuantity(item.Quantity)
+	}
+	order.Profit = order.BaseSubtotal - order.CostTotal
+	order.MarginPercent = marginPercent(order.Profit, order.BaseSubtotal)
+}
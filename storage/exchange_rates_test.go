@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeCurrency(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "", want: CurrencyTRY},
+		{in: " eur ", want: "EUR"},
+		{in: "USD", want: "USD"},
+		{in: "../", wantErr: true},
+		{in: "EU", wantErr: true},
+		{in: "EURO", wantErr: true},
+		{in: "E1R", wantErr: true},
+		{in: "E/R", wantErr: true},
+	}
+
+	t.Setenv("APPDATA", t.TempDir())
+	for _, tt := range tests {
+		got, err := normalizeCurrency(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("normalizeCurrency(%q) = %q, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("normalizeCurrency(%q) = %q, %v, want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestSaveExchangeRateRejectsBadCodes(t *testing.T) {
+	s := newTestStore(t)
+
+	for _, code := range []string{"../", "..", "TRY", "A/B"} {
+		if _, err := s.SaveExchangeRate(code, time.Now(), 1.5, ""); err == nil {
+			t.Errorf("SaveExchangeRate(%q) succeeded, want error", code)
+		}
+	}
+	if _, err := s.SaveExchangeRate("eur", time.Now(), 35.12, ""); err != nil {
+		t.Fatalf("SaveExchangeRate(eur): %v", err)
+	}
+}
+
+func TestUpdateBaseCurrency(t *testing.T) {
+	s := newTestStore(t)
+
+	if err := s.UpdateBaseCurrency("../"); err == nil {
+		t.Error("UpdateBaseCurrency(../) succeeded, want error")
+	}
+	if err := s.UpdateBaseCurrency("eur"); err != nil {
+		t.Fatalf("UpdateBaseCurrency(eur) on empty store: %v", err)
+	}
+	if got := GetBaseCurrency(); got != CurrencyEUR {
+		t.Fatalf("GetBaseCurrency() = %q, want EUR", got)
+	}
+
+	if _, err := s.SaveExchangeRate(CurrencyUSD, time.Now(), 0.9, ""); err != nil {
+		t.Fatalf("SaveExchangeRate: %v", err)
+	}
+	if err := s.UpdateBaseCurrency(CurrencyTRY); err == nil {
+		t.Error("UpdateBaseCurrency succeeded with stored exchange rates, want error")
+	}
+	if got := GetBaseCurrency(); got != CurrencyEUR {
+		t.Errorf("GetBaseCurrency() = %q after refused change, want EUR", got)
+	}
+}
@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ============================================
+// Masraflar
+// Kira, personel, alet alımı gibi stok dışı giderler. Nakit ödenen masraflar
+// kasa defterinde çıkış olarak görünür; kart ve havale ile ödenenler yalnızca
+// kâr/zarar raporuna girer.
+// ============================================
+
+const expensesDir = "expenses"
+
+// ExpenseCategories - Suggested expense categories (free text is also accepted)
+var ExpenseCategories = []string{"Kira", "Personel", "Fatura", "Alet / Ekipman", "Yakıt", "Yemek", "Kargo", "Vergi", "Bakım / Onarım", "Diğer"}
+
+// Expense - A non-stock cost of the business
+type Expense struct {
+	ID           string    `json:"id"`
+	Date         time.Time `json:"date"`
+	Category     string    `json:"category"`
+	Description  string    `json:"description"`
+	Method       string    `json:"method"`        // cash, card, transfer (nakit olanlar kasadan düşer)
+	Amount       Money     `json:"amount"`        // In Currency
+	Currency     string    `json:"currency"`      // Empty = base currency
+	ExchangeRate float64   `json:"exchange_rate"` // Rate on Date
+	BaseAmount   Money     `json:"base_amount"`   // Amount in the base currency
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// prepareExpense - Kategoriyi, yöntemi ve tutarı doğrular; ana para birimi tutarını hesaplar
+func (s *BleveStore) prepareExpense(expense *Expense) error {
+	expense.Category = strings.TrimSpace(expense.Category)
+	expense.Description = strings.TrimSpace(expense.Description)
+	if expense.Category == "" {
+		return fmt.Errorf("masraf kategorisi gerekli")
+	}
+	if !isValidPaymentMethod(expense.Method) {
+		return fmt.Errorf("geçersiz ödeme yöntemi: %q", expense.Method)
+	}
+	if expense.Amount <= 0 {
+		return fmt.Errorf("masraf tutarı sıfırdan büyük olmalı")
+	}
+	if expense.Date.IsZero() {
+		expense.Date = time.Now()
+	}
+	if expense.Method == PaymentMethodCash {
+		if err := s.checkCashDayOpen(expense.Date); err != nil {
+			return err
+		}
+	}
+
+	currency, err := normalizeCurrency(expense.Currency)
+	if err != nil {
+		return err
+	}
+	expense.Currency = currency
+	baseAmount, rate, err := s.ConvertToBase(expense.Amount, expense.Currency, expense.Date)
+	if err != nil {
+		return err
+	}
+	expense.BaseAmount = baseAmount
+	expense.ExchangeRate = rate
+
+	return nil
+}
+
+// CreateExpense - Records an expense
+func (s *BleveStore) CreateExpense(expense *Expense) (*Expense, error) {
+	if err := s.prepareExpense(expense); err != nil {
+		return nil, err
+	}
+
+	expense.ID = uuid.New().String()
+	expense.CreatedAt = time.Now()
+	expense.UpdatedAt = expense.CreatedAt
+
+	if err := s.writeJSONFile(expensesDir, expense.ID, expense); err != nil {
+		return nil, err
+	}
+	return expense, nil
+}
+
+// UpdateExpense - Updates an expense
+func (s *BleveStore) UpdateExpense(expense *Expense) (*Expense, error) {
+	existing, err := s.GetExpense(expense.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.Method == PaymentMethodCash {
+		if err := s.checkCashDayOpen(existing.Date); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.prepareExpense(expense); err != nil {
+		return nil, err
+	}
+
+	expense.CreatedAt = existing.CreatedAt
+	expense.UpdatedAt = time.Now()
+
+	if err := s.writeJSONFile(expensesDir, expense.ID, expense); err != nil {
+		return nil, err
+	}
+	return expense, nil
+}
+
+// DeleteExpense - Deletes an expense
+func (s *BleveStore) DeleteExpense(id string) error {
+	expense, err := s.GetExpense(id)
+	if err != nil {
+		return err
+	}
+	if expense.Method == PaymentMethodCash {
+		if err := s.checkCashDayOpen(expense.Date); err != nil {
+			return err
+		}
+	}
+	return s.removeJSONFile(expensesDir, id)
+}
+
+// GetExpense - Returns an expense by ID
+func (s *BleveStore) GetExpense(id string) (*Expense, error) {
+	var expense Expense
+	if err := s.readJSONFile(expensesDir, id, &expense); err != nil {
+		return nil, fmt.Errorf("masraf bulunamadı: %s", id)
+	}
+	return &expense, nil
+}
+
+// ListExpenses - Expenses, newest first; category and the dates are optional filters
+func (s *BleveStore) ListExpenses(category string, start, end time.Time) ([]*Expense, error) {
+	ids, err := s.listJSONFileIDs(expensesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	expenses := []*Expense{}
+	for _, id := range ids {
+		var expense Expense
+		if err := s.readJSONFile(expensesDir, id, &expense); err != nil {
+			continue
+		}
+		if category != "" && expense.Category != category {
+			continue
+		}
+		if !start.IsZero() && expense.Date.Before(start) {
+			continue
+		}
+		if !end.IsZero() && expense.Date.After(end) {
+			continue
+		}
+		expenses = append(expenses, &expense)
+	}
+
+	sort.Slice(expenses, func(i, j int) bool {
+		return expenses[i].Date.After(expenses[j].Date)
+	})
+
+	return expenses, nil
+}
+
+// cashExpenses - Aralıktaki nakit masraflar
+func (s *BleveStore) cashExpenses(start, end time.Time) ([]*Expense, error) {
+	expenses, err := s.ListExpenses("", start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	cash := []*Expense{}
+	for _, expense := range expenses {
+		if expense.Method == PaymentMethodCash {
+			cash = append(cash, expense)
+		}
+	}
+	return cash, nil
+}
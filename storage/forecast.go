@@ -0,0 +1,408 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ============================================
+// Talep Tahmini
+// Ürün başına haftalık tüketim serisi stok çıkışlarından (veya sipariş
+// kalemlerinden) oluşturulur ve sonraki haftalar için üç basit yöntemle tahmin
+// edilir: hareketli ortalama, üstel düzeltme ve aylık mevsimsellik (mevsimden arındırılmış
+// seriye üstel düzeltme × ayın mevsim katsayısı). Kullanılabilir + yoldaki stoğun tahmini
+// tüketimle tedarik süresinden önce bitmesi beklenen ürünler işaretlenir.
+// ============================================
+
+// Forecast sources
+const (
+	ForecastSourceMovements = "movements" // Stok çıkış hareketleri (varsayılan)
+	ForecastSourceOrders    = "orders"    // Sipariş kalemleri (taslak ve onaylı siparişler hariç)
+)
+
+// Forecast defaults
+const (
+	DefaultForecastWeeks        = 8
+	DefaultForecastHistoryWeeks = 26
+	DefaultForecastWindow       = 4   // Hareketli ortalama hafta sayısı
+	DefaultForecastAlpha        = 0.3 // Üstel düzeltme katsayısı
+	DefaultLeadTimeDays         = 7   // Tedarik süresi girilmemiş ürünler için
+)
+
+// seasonalHistoryYears - Mevsim katsayıları için geriye bakılan yıl
+const seasonalHistoryYears = 3
+
+// ForecastOptions - Parameters of a demand forecast (zero values use the defaults)
+type ForecastOptions struct {
+	ProductID    string  `json:"product_id"`    // Single product (empty = every product with consumption)
+	Weeks        int     `json:"weeks"`         // Weeks to forecast
+	HistoryWeeks int     `json:"history_weeks"` // Weeks of history in the series
+	Window       int     `json:"window"`        // Moving average window (weeks)
+	Alpha        float64 `json:"alpha"`         // Exponential smoothing factor (0-1)
+	Source       string  `json:"source"`        // "movements" or "orders"
+}
+
+// WeeklyUsage - Consumption in one week (week starts on Monday)
+type WeeklyUsage struct {
+	WeekStart string  `json:"week_start"`
+	Quantity  float64 `json:"quantity"`
+}
+
+// ForecastWeek - Forecast for one future week, per method
+type ForecastWeek struct {
+	WeekStart     string  `json:"week_start"`
+	MovingAverage float64 `json:"moving_average"`
+	Smoothed      float64 `json:"smoothed"`
+	Seasonal      float64 `json:"seasonal"`
+}
+
+// ProductForecast - Consumption history, forecast and stock-out risk of one product
+type ProductForecast struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Unit        string `json:"unit"`
+
+	History        []WeeklyUsage  `json:"history"`
+	SeasonalIndex  []float64      `json:"seasonal_index"` // January..December, 1 = average month
+	HasSeasonality bool           `json:"has_seasonality"`
+	Forecast       []ForecastWeek `json:"forecast"`
+
+	TotalMovingAverage float64 `json:"total_moving_average"`
+	TotalSmoothed      float64 `json:"total_smoothed"`
+	TotalSeasonal      float64 `json:"total_seasonal"`
+
+	Available    float64 `json:"available"`
+	OnOrder      float64 `json:"on_order"`
+	DailyUsage   float64 `json:"daily_usage"`    // Forecast usage per day (seasonal if available, else smoothed)
+	DaysOfCover  float64 `json:"days_of_cover"`  // -1 when no usage is expected
+	RunOutDate   string  `json:"run_out_date"`   // Empty when no usage is expected
+	LeadTimeDays int     `json:"lead_time_days"` // Product lead time (default 7)
+	AtRisk       bool    `json:"at_risk"`        // Runs out before a new order could arrive
+}
+
+// DemandForecast - Forecast for all (or one) products
+type DemandForecast struct {
+	GeneratedAt  time.Time          `json:"generated_at"`
+	Source       string             `json:"source"`
+	Weeks        int                `json:"weeks"`
+	HistoryWeeks int                `json:"history_weeks"`
+	Window       int                `json:"window"`
+	Alpha        float64            `json:"alpha"`
+	Products     []*ProductForecast `json:"products"`
+	AtRiskCount  int                `json:"at_risk_count"`
+}
+
+// weekStart - Tarihin haftasının pazartesi başlangıcı (yerel saat)
+func weekStart(t time.Time) time.Time {
+	t = t.In(time.Local)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	offset := (int(day.Weekday()) + 6) % 7 // Pazartesi = 0
+	return day.AddDate(0, 0, -offset)
+}
+
+// usageEvent - Bir tüketim kaydı
+type usageEvent struct {
+	productID string
+	date      time.Time
+	quantity  float64
+}
+
+// usageEvents - Kaynağa göre since'den sonraki tüketimler (güncel temel birimde)
+func (s *BleveStore) usageEvents(source string, since time.Time, products map[string]*Product) ([]usageEvent, error) {
+	var events []usageEvent
+
+	if source == ForecastSourceOrders {
+		orders, err := s.ListOrders()
+		if err != nil {
+			return nil, err
+		}
+		for _, order := range orders {
+			if !order.isSale() {
+				continue // Henüz gerçekleşmedi
+			}
+			date := order.saleDate()
+			if date.Before(since) {
+				continue
+			}
+			for i := range order.Items {
+				item := &order.Items[i]
+				if item.ProductID == "" {
+					continue
+				}
+				events = append(events, usageEvent{item.ProductID, date, item.baseQuantityFor(products[item.ProductID])})
+			}
+		}
+		return events, nil
+	}
+
+	movements, err := s.ListStockMovements()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.toCurrentUnits(movements); err != nil {
+		return nil, err
+	}
+	for _, m := range movements {
+		if m.MovementType != MovementTypeOut || m.Date.Before(since) {
+			continue
+		}
+		if m.IsReversed() || m.ReversalOf != "" {
+			continue
+		}
+		events = append(events, usageEvent{m.ProductID, m.Date, m.Amount})
+	}
+	return events, nil
+}
+
+// movingAverage - Serinin son window değerinin ortalaması
+func movingAverage(series []float64, window int) float64 {
+	if len(series) == 0 {
+		return 0
+	}
+	if window > len(series) {
+		window = len(series)
+	}
+	total := 0.0
+	for _, v := range series[len(series)-window:] {
+		total += v
+	}
+	return total / float64(window)
+}
+
+// exponentialSmoothing - Basit üstel düzeltme seviyesi (ilk değerle başlar)
+func exponentialSmoothing(series []float64, alpha float64) float64 {
+	if len(series) == 0 {
+		return 0
+	}
+	level := series[0]
+	for _, v := range series[1:] {
+		level = alpha*v + (1-alpha)*level
+	}
+	return level
+}
+
+// seasonalIndices - Ay bazında mevsim katsayıları (ay ortalaması / genel aylık ortalama)
+// En az 12 aylık geçmiş yoksa tüm katsayılar 1'dir.
+func seasonalIndices(monthly map[time.Time]float64, first time.Time, now time.Time) ([]float64, bool) {
+	indices := make([]float64, 12)
+	for i := range indices {
+		indices[i] = 1
+	}
+
+	start := time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, time.Local)
+	end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local) // İçinde bulunulan ay eksik, sayılmaz
+	var sums [12]float64
+	var counts [12]int
+	months, total := 0, 0.0
+	for m := start; m.Before(end); m = m.AddDate(0, 1, 0) {
+		sums[m.Month()-1] += monthly[m]
+		counts[m.Month()-1]++
+		total += monthly[m]
+		months++
+	}
+	if months < 12 || total == 0 {
+		return indices, false
+	}
+
+	average := total / float64(months)
+	for i := range indices {
+		if counts[i] > 0 {
+			indices[i] = math.Round(sums[i]/float64(counts[i])/average*1000) / 1000
+		}
+	}
+	return indices, true
+}
+
+// GetDemandForecast - Forecasts weekly consumption and flags products likely to run out
+func (s *BleveStore) GetDemandForecast(options ForecastOptions) (*DemandForecast, error) {
+	if options.Weeks <= 0 {
+		options.Weeks = DefaultForecastWeeks
+	}
+	if options.HistoryWeeks <= 0 {
+		options.HistoryWeeks = DefaultForecastHistoryWeeks
+	}
+	if options.Window <= 0 {
+		options.Window = DefaultForecastWindow
+	}
+	if options.Alpha <= 0 || options.Alpha > 1 {
+		options.Alpha = DefaultForecastAlpha
+	}
+	switch options.Source {
+	case "":
+		options.Source = ForecastSourceMovements
+	case ForecastSourceMovements, ForecastSourceOrders:
+	default:
+		return nil, fmt.Errorf("geçersiz tahmin kaynağı: %q", options.Source)
+	}
+
+	products, err := s.productsByID()
+	if err != nil {
+		return nil, err
+	}
+	if options.ProductID != "" && products[options.ProductID] == nil {
+		return nil, fmt.Errorf("ürün bulunamadı: %s", options.ProductID)
+	}
+
+	now := time.Now()
+	currentWeek := weekStart(now)
+	historyStart := currentWeek.AddDate(0, 0, -7*options.HistoryWeeks)
+	seasonStart := time.Date(now.Year()-seasonalHistoryYears, now.Month(), 1, 0, 0, 0, 0, time.Local)
+	since := historyStart
+	if seasonStart.Before(since) {
+		since = seasonStart
+	}
+
+	events, err := s.usageEvents(options.Source, since, products)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ürün bazında haftalık ve aylık toplamlar; içinde bulunulan hafta eksik olduğundan seriye girmez
+	weekly := make(map[string]map[time.Time]float64)
+	monthly := make(map[string]map[time.Time]float64)
+	firstUse := make(map[string]time.Time)
+	for _, e := range events {
+		if options.ProductID != "" && e.productID != options.ProductID {
+			continue
+		}
+		if weekly[e.productID] == nil {
+			weekly[e.productID] = make(map[time.Time]float64)
+			monthly[e.productID] = make(map[time.Time]float64)
+		}
+		if week := weekStart(e.date); !week.Before(historyStart) && week.Before(currentWeek) {
+			weekly[e.productID][week] += e.quantity
+		}
+		local := e.date.In(time.Local)
+		monthly[e.productID][time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, time.Local)] += e.quantity
+		if first, ok := firstUse[e.productID]; !ok || e.date.Before(first) {
+			firstUse[e.productID] = e.date
+		}
+	}
+
+	onOrder, _, err := s.openPurchaseQuantities(products)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DemandForecast{
+		GeneratedAt:  now,
+		Source:       options.Source,
+		Weeks:        options.Weeks,
+		HistoryWeeks: options.HistoryWeeks,
+		Window:       options.Window,
+		Alpha:        options.Alpha,
+		Products:     []*ProductForecast{},
+	}
+
+	round := func(q float64) float64 {
+		return math.Round(q*quantityScale) / quantityScale
+	}
+
+	for id, p := range products {
+		if options.ProductID != "" && id != options.ProductID {
+			continue
+		}
+		if options.ProductID == "" && weekly[id] == nil {
+			continue
+		}
+
+		pf := &ProductForecast{
+			ProductID:    p.ID,
+			ProductName:  p.Name,
+			Unit:         p.Unit,
+			History:      make([]WeeklyUsage, 0, options.HistoryWeeks),
+			Forecast:     make([]ForecastWeek, 0, options.Weeks),
+			Available:    p.Available(),
+			OnOrder:      round(onOrder[id]),
+			LeadTimeDays: p.LeadTimeDays,
+		}
+		if pf.LeadTimeDays == 0 {
+			pf.LeadTimeDays = DefaultLeadTimeDays
+		}
+
+		series := make([]float64, 0, options.HistoryWeeks)
+		for week := historyStart; week.Before(currentWeek); week = week.AddDate(0, 0, 7) {
+			quantity := round(weekly[id][week])
+			series = append(series, quantity)
+			pf.History = append(pf.History, WeeklyUsage{WeekStart: week.Format("2006-01-02"), Quantity: quantity})
+		}
+
+		average := movingAverage(series, options.Window)
+		level := exponentialSmoothing(series, options.Alpha)
+		first, ok := firstUse[id]
+		if !ok {
+			first = now
+		}
+		pf.SeasonalIndex, pf.HasSeasonality = seasonalIndices(monthly[id], first, now)
+		monthIndex := func(week time.Time) float64 {
+			return pf.SeasonalIndex[week.AddDate(0, 0, 3).Month()-1] // Haftanın ayı ortasına göre
+		}
+
+		// Mevsimsel tahmin: mevsimden arındırılmış serinin seviyesi × ayın katsayısı
+		baseLevel := level
+		if pf.HasSeasonality {
+			adjusted := make([]float64, len(series))
+			for i, v := range series {
+				if index := monthIndex(historyStart.AddDate(0, 0, 7*i)); index > 0 {
+					adjusted[i] = v / index
+				}
+			}
+			baseLevel = exponentialSmoothing(adjusted, options.Alpha)
+		}
+
+		for i := 0; i < options.Weeks; i++ {
+			week := currentWeek.AddDate(0, 0, 7*i)
+			seasonal := baseLevel * monthIndex(week)
+			pf.Forecast = append(pf.Forecast, ForecastWeek{
+				WeekStart:     week.Format("2006-01-02"),
+				MovingAverage: round(average),
+				Smoothed:      round(level),
+				Seasonal:      round(seasonal),
+			})
+			pf.TotalMovingAverage += average
+			pf.TotalSmoothed += level
+			pf.TotalSeasonal += seasonal
+		}
+		pf.TotalMovingAverage = round(pf.TotalMovingAverage)
+		pf.TotalSmoothed = round(pf.TotalSmoothed)
+		pf.TotalSeasonal = round(pf.TotalSeasonal)
+
+		// Risk: yakın haftaların tahmini tüketimiyle stok kaç gün yeter
+		weeklyUsage := level
+		if pf.HasSeasonality && len(pf.Forecast) > 0 {
+			weeklyUsage = pf.Forecast[0].Seasonal
+		}
+		pf.DailyUsage = round(weeklyUsage / 7)
+		pf.DaysOfCover = -1
+		if pf.DailyUsage > 0 {
+			position := math.Max(0, pf.Available+pf.OnOrder)
+			pf.DaysOfCover = math.Round(position/pf.DailyUsage*10) / 10
+			pf.RunOutDate = now.AddDate(0, 0, int(pf.DaysOfCover)).Format("2006-01-02")
+			pf.AtRisk = pf.DaysOfCover < float64(pf.LeadTimeDays)
+		}
+		if pf.AtRisk {
+			result.AtRiskCount++
+		}
+
+		result.Products = append(result.Products, pf)
+	}
+
+	// Riskliler önce, sonra en az gün yetenler; tüketimi olmayanlar en sonda
+	sort.Slice(result.Products, func(i, j int) bool {
+		a, b := result.Products[i], result.Products[j]
+		if a.AtRisk != b.AtRisk {
+			return a.AtRisk
+		}
+		if (a.DaysOfCover < 0) != (b.DaysOfCover < 0) {
+			return b.DaysOfCover < 0
+		}
+		if a.DaysOfCover != b.DaysOfCover {
+			return a.DaysOfCover < b.DaysOfCover
+		}
+		return a.ProductName < b.ProductName
+	})
+
+	return result, nil
+}
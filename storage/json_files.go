@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ============================================
+// JSON dosya yardımcıları
+// Index'e girmeyen kayıtlar (kurlar, ödemeler vb.) <dataPath>/<dir>/<id>.json
+// olarak saklanır; aynı düzen orders/, customers/ ve products/ için de kullanılır.
+// ============================================
+
+// writeJSONFile - Kaydı JSON olarak dosyaya yazar
+func (s *BleveStore) writeJSONFile(dir, id string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("JSON dönüştürme hatası: %w", err)
+	}
+
+	file := filepath.Join(s.dataPath, dir, id+".json")
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return fmt.Errorf("dosya yazma hatası: %w", err)
+	}
+
+	return nil
+}
+
+// readJSONFile - JSON dosyasını okuyup v'ye çözümler
+func (s *BleveStore) readJSONFile(dir, id string, v interface{}) error {
+	data, err := os.ReadFile(filepath.Join(s.dataPath, dir, id+".json"))
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("JSON çözümleme hatası: %w", err)
+	}
+
+	return nil
+}
+
+// removeJSONFile - Kayıt dosyasını siler (yoksa hata vermez)
+func (s *BleveStore) removeJSONFile(dir, id string) error {
+	err := os.Remove(filepath.Join(s.dataPath, dir, id+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("dosya silme hatası: %w", err)
+	}
+	return nil
+}
+
+// listJSONFileIDs - Dizindeki kayıtların ID'lerini döner (dizin yoksa boş)
+func (s *BleveStore) listJSONFileIDs(dir string) ([]string, error) {
+	fullDir := filepath.Join(s.dataPath, dir)
+
+	if _, err := os.Stat(fullDir); os.IsNotExist(err) {
+		return []string{}, nil
+	}
+
+	entries, err := os.ReadDir(fullDir)
+	if err != nil {
+		return nil, fmt.Errorf("dizin okunamadı: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		ids = append(ids, entry.Name()[:len(entry.Name())-5])
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,443 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ============================================
+// Depolar / Stok Yerleri
+// Her ürünün stoğu yer bazında Product.LocationStock'ta tutulur;
+// Product.StockQuantity tüm yerlerin toplamıdır. Yer belirtilmeyen
+// işlemler varsayılan yere (ana dükkan) yazılır.
+// ============================================
+
+// DefaultLocationID - Varsayılan yer; eski stok buraya taşınır
+const DefaultLocationID = "main"
+
+const locationsDir = "locations"
+
+// Location - Stok yeri (dükkan, araç, depo)
+type Location struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Note      string    `json:"note"`
+	IsDefault bool      `json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LocationStockLevel - Stock of one product at one location
+type LocationStockLevel struct {
+	LocationID    string  `json:"location_id"`
+	LocationName  string  `json:"location_name"`
+	Quantity      float64 `json:"quantity"`
+	CriticalStock int     `json:"critical_stock"`
+}
+
+// CriticalLocationStock - Product below its critical level at a location
+type CriticalLocationStock struct {
+	Product       *Product `json:"product"`
+	LocationID    string   `json:"location_id"`
+	LocationName  string   `json:"location_name"`
+	Quantity      float64  `json:"quantity"`
+	CriticalStock int      `json:"critical_stock"`
+}
+
+// ensureDefaultLocation - Varsayılan yeri oluşturur ve yer bilgisi olmayan ürün stoklarını ona taşır
+func (s *BleveStore) ensureDefaultLocation() error {
+	var location Location
+	if err := s.readJSONFile(locationsDir, DefaultLocationID, &location); err != nil {
+		location = Location{
+			ID:        DefaultLocationID,
+			Name:      "Ana Dükkan",
+			IsDefault: true,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		if err := s.writeJSONFile(locationsDir, location.ID, &location); err != nil {
+			return err
+		}
+	}
+
+	products, err := s.ListProducts()
+	if err != nil {
+		return err
+	}
+	for _, p := range products {
+		if len(p.LocationStock) > 0 || p.StockQuantity == 0 {
+			continue
+		}
+		p.LocationStock = map[string]float64{DefaultLocationID: p.StockQuantity}
+		if err := s.SaveProduct(p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListLocations - Lists locations, default first
+func (s *BleveStore) ListLocations() ([]*Location, error) {
+	ids, err := s.listJSONFileIDs(locationsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := []*Location{}
+	for _, id := range ids {
+		var location Location
+		if err := s.readJSONFile(locationsDir, id, &location); err != nil {
+			continue
+		}
+		locations = append(locations, &location)
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].IsDefault != locations[j].IsDefault {
+			return locations[i].IsDefault
+		}
+		return locations[i].Name < locations[j].Name
+	})
+
+	return locations, nil
+}
+
+// GetLocation - Returns a location by ID
+func (s *BleveStore) GetLocation(id string) (*Location, error) {
+	var location Location
+	if err := s.readJSONFile(locationsDir, id, &location); err != nil {
+		return nil, fmt.Errorf("stok yeri bulunamadı: %s", id)
+	}
+	return &location, nil
+}
+
+// resolveLocation - Boş ID için varsayılan yer; diğerlerinin var olduğunu doğrular
+func (s *BleveStore) resolveLocation(id string) (string, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return DefaultLocationID, nil
+	}
+	if _, err := s.GetLocation(id); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// CreateLocation - Creates a new location
+func (s *BleveStore) CreateLocation(name, note string) (*Location, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("stok yeri adı boş olamaz")
+	}
+
+	location := &Location{
+		ID:        uuid.New().String(),
+		Name:      name,
+		Note:      strings.TrimSpace(note),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if err := s.writeJSONFile(locationsDir, location.ID, location); err != nil {
+		return nil, err
+	}
+
+	return location, nil
+}
+
+// UpdateLocation - Renames a location or changes its note
+func (s *BleveStore) UpdateLocation(id, name, note string) error {
+	location, err := s.GetLocation(id)
+	if err != nil {
+		return err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("stok yeri adı boş olamaz")
+	}
+
+	location.Name = name
+	location.Note = strings.TrimSpace(note)
+	location.UpdatedAt = time.Now()
+
+	return s.writeJSONFile(locationsDir, location.ID, location)
+}
+
+// DeleteLocation - Deletes an empty, non-default location
+func (s *BleveStore) DeleteLocation(id string) error {
+	location, err := s.GetLocation(id)
+	if err != nil {
+		return err
+	}
+	if location.IsDefault {
+		return fmt.Errorf("varsayılan stok yeri silinemez")
+	}
+
+	products, err := s.ListProducts()
+	if err != nil {
+		return err
+	}
+	for _, p := range products {
+		if math.Abs(p.LocationStock[id]) >= stockTolerance {
+			return fmt.Errorf("%s yerinde stok var (%s)", location.Name, p.Name)
+		}
+	}
+
+	return s.removeJSONFile(locationsDir, id)
+}
+
+// QuantityAt - Ürünün verilen yerdeki stoğu
+// Yer bilgisi olmayan (taşınmamış) ürünlerde tüm stok varsayılan yerdedir.
+func (p *Product) QuantityAt(locationID string) float64 {
+	if len(p.LocationStock) == 0 {
+		if locationID == DefaultLocationID {
+			return p.StockQuantity
+		}
+		return 0
+	}
+	return p.LocationStock[locationID]
+}
+
+// CriticalStockAt - Ürünün verilen yerdeki kritik stok seviyesi
+func (p *Product) CriticalStockAt(locationID string) int {
+	if level, ok := p.LocationCritical[locationID]; ok && level > 0 {
+		return level
+	}
+	if p.CriticalStock > 0 {
+		return p.CriticalStock
+	}
+	return 3 // Default
+}
+
+// applyStockDelta - Yer stoğunu ve toplam stoğu değiştirir (ürün kaydedilmez)
+func (p *Product) applyStockDelta(locationID string, delta float64) {
+	if len(p.LocationStock) == 0 {
+		p.LocationStock = make(map[string]float64)
+		if p.StockQuantity != 0 {
+			p.LocationStock[DefaultLocationID] = p.StockQuantity
+		}
+	}
+
+	quantity := math.Round((p.LocationStock[locationID]+delta)*quantityScale) / quantityScale
+	if quantity == 0 {
+		delete(p.LocationStock, locationID)
+	} else {
+		p.LocationStock[locationID] = quantity
+	}
+
+	p.StockQuantity = math.Round((p.StockQuantity+delta)*quantityScale) / quantityScale
+}
+
+// GetProductLocationStock - Stock of a product at every location
+func (s *BleveStore) GetProductLocationStock(productID string) ([]*LocationStockLevel, error) {
+	product, err := s.GetProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := s.ListLocations()
+	if err != nil {
+		return nil, err
+	}
+
+	levels := []*LocationStockLevel{}
+	for _, l := range locations {
+		levels = append(levels, &LocationStockLevel{
+			LocationID:    l.ID,
+			LocationName:  l.Name,
+			Quantity:      product.QuantityAt(l.ID),
+			CriticalStock: product.CriticalStockAt(l.ID),
+		})
+	}
+
+	return levels, nil
+}
+
+// SetLocationCriticalStock - Sets a product's critical level at a location (0 = use product level)
+func (s *BleveStore) SetLocationCriticalStock(productID, locationID string, level int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, err := s.GetProduct(productID)
+	if err != nil {
+		return err
+	}
+	locationID, err = s.resolveLocation(locationID)
+	if err != nil {
+		return err
+	}
+
+	if level <= 0 {
+		delete(product.LocationCritical, locationID)
+	} else {
+		if product.LocationCritical == nil {
+			product.LocationCritical = make(map[string]int)
+		}
+		product.LocationCritical[locationID] = level
+	}
+	product.UpdatedAt = time.Now()
+
+	return s.SaveProduct(product)
+}
+
+// TransferStock - Moves stock between two locations with a "transfer" movement
+// Serial-tracked products must name the units being moved.
+func (s *BleveStore) TransferStock(productID, fromLocationID, toLocationID string, amount float64, serialNumbers []string, note string) (*StockMovement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, err := s.GetProduct(productID)
+	if err != nil {
+		return nil, fmt.Errorf("product not found: %w", err)
+	}
+
+	fromLocationID, err = s.resolveLocation(fromLocationID)
+	if err != nil {
+		return nil, err
+	}
+	toLocationID, err = s.resolveLocation(toLocationID)
+	if err != nil {
+		return nil, err
+	}
+	if fromLocationID == toLocationID {
+		return nil, fmt.Errorf("kaynak ve hedef stok yeri aynı")
+	}
+
+	if err := ValidateQuantity(amount, product.Unit); err != nil {
+		return nil, err
+	}
+	if available := product.QuantityAt(fromLocationID); available < amount {
+		return nil, fmt.Errorf("insufficient stock: available %.2f, requested %.2f", available, amount)
+	}
+
+	var serials []*SerialNumber
+	if product.SerialTracking {
+		numbers, err := normalizeSerialList(serialNumbers)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkSerialCount(product, numbers, amount); err != nil {
+			return nil, err
+		}
+		if serials, err = s.checkSerialsInStock(product, numbers, fromLocationID, ""); err != nil {
+			return nil, err
+		}
+		serialNumbers = numbers
+	}
+
+	// Lots move with the stock (FEFO)
+	var allocations []LotAllocation
+	if product.LotTracking {
+		allocations, _ = product.consumeLots(fromLocationID, amount, "")
+		product.restoreLots(toLocationID, allocations, time.Now())
+	}
+
+	product.applyStockDelta(fromLocationID, -amount)
+	product.applyStockDelta(toLocationID, amount)
+	product.UpdatedAt = time.Now()
+
+	if err := s.SaveProduct(product); err != nil {
+		return nil, fmt.Errorf("stok güncellenemedi: %w", err)
+	}
+
+	movement := &StockMovement{
+		ID:             uuid.New().String(),
+		ProductID:      product.ID,
+		ProductName:    product.Name,
+		MovementType:   MovementTypeTransfer,
+		Amount:         amount,
+		Note:           note,
+		Date:           time.Now(),
+		UnitCost:       product.AverageCost,
+		LocationID:     fromLocationID,
+		ToLocationID:   toLocationID,
+		LotAllocations: allocations,
+		BaseUnit:       product.Unit,
+	}
+	if product.SerialTracking {
+		movement.SerialNumbers = serialNumbers
+	}
+
+	if err := s.SaveStockMovement(movement); err != nil {
+		return nil, err
+	}
+
+	return movement, s.updateSerials(serials, SerialInStock, toLocationID, SerialEvent{
+		Type:       SerialEventTransfer,
+		Date:       movement.Date,
+		MovementID: movement.ID,
+		LocationID: toLocationID,
+		Note:       note,
+	})
+}
+
+// GetCriticalStockByLocation - Products below their critical level, per location
+func (s *BleveStore) GetCriticalStockByLocation() ([]*CriticalLocationStock, error) {
+	products, err := s.ListProducts()
+	if err != nil {
+		return nil, err
+	}
+
+	locations, err := s.ListLocations()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*CriticalLocationStock{}
+	for _, l := range locations {
+		for _, p := range products {
+			quantity := p.QuantityAt(l.ID)
+			critical := p.CriticalStockAt(l.ID)
+			if quantity >= float64(critical) {
+				continue
+			}
+			// Non-default locations only report products they are meant to stock
+			if !l.IsDefault && quantity == 0 {
+				if _, ok := p.LocationCritical[l.ID]; !ok {
+					continue
+				}
+			}
+			result = append(result, &CriticalLocationStock{
+				Product:       p,
+				LocationID:    l.ID,
+				LocationName:  l.Name,
+				Quantity:      quantity,
+				CriticalStock: critical,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// clone - Ürünün yer haritaları ve partileri dahil kopyası (geri alma için)
+func (p *Product) clone() *Product {
+	c := *p
+	if p.Lots != nil {
+		c.Lots = make([]*StockLot, len(p.Lots))
+		for i, lot := range p.Lots {
+			copied := *lot
+			c.Lots[i] = &copied
+		}
+	}
+	if p.LocationStock != nil {
+		c.LocationStock = make(map[string]float64, len(p.LocationStock))
+		for k, v := range p.LocationStock {
+			c.LocationStock[k] = v
+		}
+	}
+	if p.LocationCritical != nil {
+		c.LocationCritical = make(map[string]int, len(p.LocationCritical))
+		for k, v := range p.LocationCritical {
+			c.LocationCritical[k] = v
+		}
+	}
+	return &c
+}
@@ -0,0 +1,269 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ============================================
+// Parti (Lot) ve Son Kullanma Takibi
+// LotTracking açık ürünlerde stok girişi parti numarası ve son kullanma tarihiyle
+// kaydedilir; çıkışlar seçilen partiden, seçilmezse FEFO (önce son kullanma tarihi
+// en yakın olan) ile düşülür. Takip açılmadan önceki stok partisiz kabul edilir ve
+// partilerden sonra tüketilir.
+// ============================================
+
+// StockLot - Quantity of one lot at one location
+type StockLot struct {
+	LotNumber  string     `json:"lot_number"`
+	ExpiryDate *time.Time `json:"expiry_date,omitempty"`
+	LocationID string     `json:"location_id"`
+	Quantity   float64    `json:"quantity"`
+	ReceivedAt time.Time  `json:"received_at"`
+}
+
+// LotAllocation - Part of a movement taken from (or returned to) a lot
+type LotAllocation struct {
+	LotNumber  string     `json:"lot_number"`
+	ExpiryDate *time.Time `json:"expiry_date,omitempty"`
+	Quantity   float64    `json:"quantity"`
+}
+
+// ExpiringLot - Lot line in the expiry report
+type ExpiringLot struct {
+	ProductID    string    `json:"product_id"`
+	ProductName  string    `json:"product_name"`
+	Category     string    `json:"category"`
+	Unit         string    `json:"unit"`
+	LocationID   string    `json:"location_id"`
+	LocationName string    `json:"location_name"`
+	LotNumber    string    `json:"lot_number"`
+	ExpiryDate   time.Time `json:"expiry_date"`
+	DaysLeft     int       `json:"days_left"` // Negative when expired
+	Quantity     float64   `json:"quantity"`
+	Value        Money     `json:"value"` // Quantity × average cost
+}
+
+// ExpiryReport - Expired and soon-to-expire lots
+type ExpiryReport struct {
+	Date          string         `json:"date"`
+	WithinDays    int            `json:"within_days"`
+	Expired       []*ExpiringLot `json:"expired"`
+	Expiring      []*ExpiringLot `json:"expiring"`
+	ExpiredValue  Money          `json:"expired_value"`
+	ExpiringValue Money          `json:"expiring_value"`
+}
+
+// ParseExpiryDate - "2006-01-02" biçimindeki son kullanma tarihini çözer (boşsa nil)
+func ParseExpiryDate(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("geçersiz son kullanma tarihi: %q", value)
+	}
+	return &date, nil
+}
+
+// sameExpiry - İki son kullanma tarihi aynı gün mü (ikisi de boşsa eşit)
+func sameExpiry(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
+
+// addLot - Partiye miktar ekler; aynı yerde aynı parti varsa birleştirir
+func (p *Product) addLot(locationID, lotNumber string, expiry *time.Time, amount float64, receivedAt time.Time) {
+	for _, lot := range p.Lots {
+		if lot.LocationID == locationID && lot.LotNumber == lotNumber && sameExpiry(lot.ExpiryDate, expiry) {
+			lot.Quantity = math.Round((lot.Quantity+amount)*quantityScale) / quantityScale
+			return
+		}
+	}
+	p.Lots = append(p.Lots, &StockLot{
+		LotNumber:  lotNumber,
+		ExpiryDate: expiry,
+		LocationID: locationID,
+		Quantity:   amount,
+		ReceivedAt: receivedAt,
+	})
+}
+
+// lotsAt - Yerdeki partiler FEFO sırasıyla (tarihsizler en sonda, sonra giriş sırası)
+func (p *Product) lotsAt(locationID string) []*StockLot {
+	var lots []*StockLot
+	for _, lot := range p.Lots {
+		if lot.LocationID == locationID && lot.Quantity > 0 {
+			lots = append(lots, lot)
+		}
+	}
+
+	sort.SliceStable(lots, func(i, j int) bool {
+		a, b := lots[i].ExpiryDate, lots[j].ExpiryDate
+		switch {
+		case a != nil && b != nil && !a.Equal(*b):
+			return a.Before(*b)
+		case a != nil && b == nil:
+			return true
+		case a == nil && b != nil:
+			return false
+		}
+		return lots[i].ReceivedAt.Before(lots[j].ReceivedAt)
+	})
+
+	return lots
+}
+
+// consumeLots - Yerdeki partilerden miktar düşer ve dağılımı döner
+// lotNumber verilmişse yalnızca o partiden düşülür (yetmezse hata); verilmemişse FEFO
+// uygulanır, partilere sığmayan kısım partisiz stoktan düşülmüş sayılır.
+func (p *Product) consumeLots(locationID string, amount float64, lotNumber string) ([]LotAllocation, error) {
+	lotNumber = strings.TrimSpace(lotNumber)
+
+	var allocations []LotAllocation
+	remaining := amount
+	for _, lot := range p.lotsAt(locationID) {
+		if remaining < stockTolerance {
+			break
+		}
+		if lotNumber != "" && lot.LotNumber != lotNumber {
+			continue
+		}
+
+		take := math.Min(lot.Quantity, remaining)
+		lot.Quantity = math.Round((lot.Quantity-take)*quantityScale) / quantityScale
+		remaining = math.Round((remaining-take)*quantityScale) / quantityScale
+		allocations = append(allocations, LotAllocation{LotNumber: lot.LotNumber, ExpiryDate: lot.ExpiryDate, Quantity: take})
+	}
+
+	if lotNumber != "" && remaining >= stockTolerance {
+		// Düşülenleri geri koy
+		p.restoreLots(locationID, allocations, time.Now())
+		return nil, fmt.Errorf("%s partisinde yeterli stok yok (eksik %.2f)", lotNumber, remaining)
+	}
+
+	p.pruneLots()
+	return allocations, nil
+}
+
+// restoreLots - Dağılımdaki miktarları partilere geri ekler (iptal, aktarım)
+// Parti takibi kapatılmış ürünlerde bir şey yapmaz.
+func (p *Product) restoreLots(locationID string, allocations []LotAllocation, receivedAt time.Time) {
+	if !p.LotTracking {
+		return
+	}
+	for _, a := range allocations {
+		p.addLot(locationID, a.LotNumber, a.ExpiryDate, a.Quantity, receivedAt)
+	}
+}
+
+// pruneLots - Biten partileri kaldırır
+func (p *Product) pruneLots() {
+	lots := p.Lots[:0]
+	for _, lot := range p.Lots {
+		if lot.Quantity >= stockTolerance {
+			lots = append(lots, lot)
+		}
+	}
+	p.Lots = lots
+}
+
+// SetProductLotTracking - Turns lot tracking on or off for a product
+// Turning it off drops the lot details; the stock quantities are not changed.
+func (s *BleveStore) SetProductLotTracking(productID string, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, err := s.GetProduct(productID)
+	if err != nil {
+		return err
+	}
+
+	product.LotTracking = enabled
+	if !enabled {
+		product.Lots = nil
+	}
+	product.UpdatedAt = time.Now()
+
+	return s.SaveProduct(product)
+}
+
+// GetExpiryReport - Lots that have expired or expire within the given number of days
+func (s *BleveStore) GetExpiryReport(withinDays int) (*ExpiryReport, error) {
+	if withinDays <= 0 {
+		withinDays = 30
+	}
+
+	products, err := s.ListProducts()
+	if err != nil {
+		return nil, err
+	}
+
+	locationNames := make(map[string]string)
+	if locations, err := s.ListLocations(); err == nil {
+		for _, l := range locations {
+			locationNames[l.ID] = l.Name
+		}
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	limit := today.AddDate(0, 0, withinDays)
+
+	report := &ExpiryReport{
+		Date:       today.Format("2006-01-02"),
+		WithinDays: withinDays,
+		Expired:    []*ExpiringLot{},
+		Expiring:   []*ExpiringLot{},
+	}
+
+	for _, p := range products {
+		if !p.LotTracking {
+			continue
+		}
+		for _, lot := range p.Lots {
+			if lot.ExpiryDate == nil || lot.Quantity <= 0 || lot.ExpiryDate.After(limit) {
+				continue
+			}
+
+			expiry := time.Date(lot.ExpiryDate.Year(), lot.ExpiryDate.Month(), lot.ExpiryDate.Day(), 0, 0, 0, 0, time.Local)
+			item := &ExpiringLot{
+				ProductID:    p.ID,
+				ProductName:  p.Name,
+				Category:     p.Category,
+				Unit:         p.Unit,
+				LocationID:   lot.LocationID,
+				LocationName: locationNames[lot.LocationID],
+				LotNumber:    lot.LotNumber,
+				ExpiryDate:   expiry,
+				DaysLeft:     int(math.Round(expiry.Sub(today).Hours() / 24)),
+				Quantity:     lot.Quantity,
+				Value:        p.AverageCost.MulQuantity(lot.Quantity),
+			}
+
+			if expiry.Before(today) {
+				report.Expired = append(report.Expired, item)
+				report.ExpiredValue += item.Value
+			} else {
+				report.Expiring = append(report.Expiring, item)
+				report.ExpiringValue += item.Value
+			}
+		}
+	}
+
+	byExpiry := func(items []*ExpiringLot) {
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].ExpiryDate.Before(items[j].ExpiryDate)
+		})
+	}
+	byExpiry(report.Expired)
+	byExpiry(report.Expiring)
+
+	return report, nil
+}
@@ -0,0 +1,270 @@
+package storage
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ============================================
+// Bakım Hatırlatmaları
+// Ürün kategorisi ("Yağ") veya bakım türü ("Triger değişimi") başına ay ve km
+// aralığı tanımlanır. Her aracın bu türdeki son bakımından (satış sayılan
+// sipariş ya da araca yapılan stok çıkışı) sonraki bakım tarihi ve km'si
+// hesaplanır; km aracın son bilinen km'si ile karşılaştırılır. Kalemin bakım
+// türü boşsa ürünün kategorisi kullanılır. Liste müşteri telefonu ve WhatsApp
+// linkiyle döner.
+// ============================================
+
+// ServiceInterval - Maintenance interval of a category or service type
+type ServiceInterval struct {
+	Months     int `json:"months"`     // 0 = no date limit
+	Kilometers int `json:"kilometers"` // 0 = no mileage limit
+}
+
+// DefaultServiceIntervals - Default maintenance intervals (category or service type -> interval)
+var DefaultServiceIntervals = map[string]ServiceInterval{
+	"Yağ":  {Months: 12, Kilometers: 10000},
+	"Fren": {Months: 24, Kilometers: 30000},
+}
+
+// Reminder statuses
+const (
+	ReminderOverdue  = "overdue"  // Bakım zamanı geçti
+	ReminderUpcoming = "upcoming" // Bakım zamanı yaklaşıyor
+)
+
+// Default look-ahead of the reminder list
+const (
+	DefaultReminderDays = 30
+	DefaultReminderKm   = 1000
+)
+
+// MaintenanceReminder - Next maintenance of one service type for a vehicle
+type MaintenanceReminder struct {
+	VehicleID    string `json:"vehicle_id"`
+	VehiclePlate string `json:"vehicle_plate"`
+	Make         string `json:"make"`
+	Model        string `json:"model"`
+	CustomerID   string `json:"customer_id"`
+	CustomerName string `json:"customer_name"`
+	Phone        string `json:"phone"`
+
+	ServiceType        string          `json:"service_type"`
+	Interval           ServiceInterval `json:"interval"`
+	LastServiceDate    time.Time       `json:"last_service_date"`
+	LastServiceMileage int             `json:"last_service_mileage"` // 0 = unknown
+	LastOrderID        string          `json:"last_order_id,omitempty"`
+
+	DueDate        *time.Time `json:"due_date,omitempty"`
+	DueMileage     int        `json:"due_mileage,omitempty"`
+	CurrentMileage int        `json:"current_mileage"`        // Vehicle's last known mileage
+	DaysLeft       *int       `json:"days_left,omitempty"`    // Negative when overdue
+	KmLeft         *int       `json:"km_left,omitempty"`      // Negative when overdue
+	Status         string     `json:"status"`                 // overdue or upcoming
+	Message        string     `json:"message"`                // Ready-to-send reminder text
+	WhatsAppURL    string     `json:"whatsapp_url,omitempty"` // wa.me link with the message
+}
+
+// lastService - Bir aracın bir bakım türündeki son bakımı
+type lastService struct {
+	date    time.Time
+	mileage int
+	orderID string
+}
+
+// whatsAppPhone - Telefonu wa.me için yalnızca rakamlara indirger ("+90 532 ..." -> "90532...")
+func whatsAppPhone(phone string) string {
+	var b strings.Builder
+	for _, r := range phone {
+		if unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// lastServices - Araç ve bakım türü başına son bakım (vehicleID -> tür -> bakım)
+func (s *BleveStore) lastServices(intervals map[string]ServiceInterval) (map[string]map[string]*lastService, error) {
+	products, err := s.ListProducts()
+	if err != nil {
+		return nil, err
+	}
+	categoryByID := make(map[string]string, len(products))
+	for _, p := range products {
+		categoryByID[p.ID] = p.Category
+	}
+
+	result := make(map[string]map[string]*lastService)
+	record := func(vehicleID, serviceType string, service lastService) {
+		if _, ok := intervals[serviceType]; !ok {
+			return
+		}
+		if result[vehicleID] == nil {
+			result[vehicleID] = make(map[string]*lastService)
+		}
+		if last := result[vehicleID][serviceType]; last == nil || service.date.After(last.date) {
+			result[vehicleID][serviceType] = &service
+		}
+	}
+
+	orders, err := s.ListOrders()
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if order.VehicleID == "" || !order.isSale() {
+			continue
+		}
+		service := lastService{date: order.saleDate(), mileage: order.lastMileage(), orderID: order.ID}
+		for i := range order.Items {
+			item := &order.Items[i]
+			serviceType := item.ServiceType
+			if serviceType == "" {
+				serviceType = categoryByID[item.ProductID]
+			}
+			record(order.VehicleID, serviceType, service)
+		}
+	}
+
+	movements, err := s.ListStockMovements()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range movements {
+		if m.VehicleID == "" || m.OrderID != "" || m.MovementType != MovementTypeOut || m.IsReversed() || m.ReversalOf != "" {
+			continue
+		}
+		record(m.VehicleID, categoryByID[m.ProductID], lastService{date: m.Date})
+	}
+
+	return result, nil
+}
+
+// reminderMessage - Müşteriye gönderilecek hatırlatma metni
+func reminderMessage(r *MaintenanceReminder) string {
+	var b strings.Builder
+	name := r.CustomerName
+	if name == "" {
+		name = "Değerli müşterimiz"
+	} else {
+		name = "Sayın " + name
+	}
+	fmt.Fprintf(&b, "%s, %s plakalı aracınızın %s bakımı", name, r.VehiclePlate, strings.ToLowerSpecial(unicode.TurkishCase, r.ServiceType))
+	if r.Status == ReminderOverdue {
+		b.WriteString(" zamanı geldi.")
+	} else {
+		b.WriteString(" zamanı yaklaşıyor.")
+	}
+	fmt.Fprintf(&b, " Son bakım: %s", r.LastServiceDate.In(time.Local).Format("02.01.2006"))
+	if r.LastServiceMileage > 0 {
+		fmt.Fprintf(&b, ", %d km", r.LastServiceMileage)
+	}
+	b.WriteString(". Randevu için bize ulaşabilirsiniz.")
+	return b.String()
+}
+
+// GetMaintenanceReminders - Overdue maintenance and maintenance due within the given days or km
+// Overdue first, then by due date.
+func (s *BleveStore) GetMaintenanceReminders(at time.Time, withinDays, withinKm int) ([]*MaintenanceReminder, error) {
+	if withinDays < 0 || withinKm < 0 {
+		return nil, fmt.Errorf("hatırlatma aralığı negatif olamaz")
+	}
+
+	intervals := GetServiceIntervals()
+	services, err := s.lastServices(intervals)
+	if err != nil {
+		return nil, err
+	}
+
+	vehicles, err := s.ListVehicles("")
+	if err != nil {
+		return nil, err
+	}
+
+	reminders := []*MaintenanceReminder{}
+	for _, vehicle := range vehicles {
+		if len(services[vehicle.ID]) == 0 {
+			continue
+		}
+
+		phone := ""
+		if customer, err := s.GetCustomer(vehicle.CustomerID); err == nil {
+			phone = customer.Phone
+		}
+
+		for serviceType, last := range services[vehicle.ID] {
+			interval := intervals[serviceType]
+			r := &MaintenanceReminder{
+				VehicleID:          vehicle.ID,
+				VehiclePlate:       vehicle.Plate,
+				Make:               vehicle.Make,
+				Model:              vehicle.Model,
+				CustomerID:         vehicle.CustomerID,
+				CustomerName:       vehicle.CustomerName,
+				Phone:              phone,
+				ServiceType:        serviceType,
+				Interval:           interval,
+				LastServiceDate:    last.date,
+				LastServiceMileage: last.mileage,
+				LastOrderID:        last.orderID,
+				CurrentMileage:     vehicle.Mileage,
+			}
+
+			overdue, upcoming := false, false
+			if interval.Months > 0 {
+				due := last.date.AddDate(0, interval.Months, 0)
+				days := int(due.Sub(at).Hours() / 24)
+				r.DueDate = &due
+				r.DaysLeft = &days
+				overdue = overdue || !at.Before(due)
+				upcoming = upcoming || days <= withinDays
+			}
+			// Km, yalnızca bakımdaki km biliniyorsa hesaplanır
+			if interval.Kilometers > 0 && last.mileage > 0 {
+				r.DueMileage = last.mileage + interval.Kilometers
+				km := r.DueMileage - vehicle.Mileage
+				r.KmLeft = &km
+				overdue = overdue || km <= 0
+				upcoming = upcoming || km <= withinKm
+			}
+
+			switch {
+			case overdue:
+				r.Status = ReminderOverdue
+			case upcoming:
+				r.Status = ReminderUpcoming
+			default:
+				continue
+			}
+
+			r.Message = reminderMessage(r)
+			if digits := whatsAppPhone(phone); digits != "" {
+				r.WhatsAppURL = "https://wa.me/" + digits + "?text=" + strings.ReplaceAll(url.QueryEscape(r.Message), "+", "%20")
+			}
+			reminders = append(reminders, r)
+		}
+	}
+
+	sort.Slice(reminders, func(i, j int) bool {
+		a, b := reminders[i], reminders[j]
+		if a.Status != b.Status {
+			return a.Status == ReminderOverdue
+		}
+		if (a.DueDate == nil) != (b.DueDate == nil) {
+			return a.DueDate != nil
+		}
+		if a.DueDate != nil && !a.DueDate.Equal(*b.DueDate) {
+			return a.DueDate.Before(*b.DueDate)
+		}
+		if a.VehiclePlate != b.VehiclePlate {
+			return a.VehiclePlate < b.VehiclePlate
+		}
+		return a.ServiceType < b.ServiceType
+	})
+
+	return reminders, nil
+}
@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ============================================
+// Money - Sabit noktalı para birimi
+// Tutarlar kuruş (1/100) cinsinden int64 olarak tutulur.
+//
+// Yuvarlama kuralları:
+//   - Her işlem sonucu en yakın kuruşa yuvarlanır, yarım kuruş sıfırdan uzağa
+//     yuvarlanır (1,005 → 1,01; -1,005 → -1,01)
+//   - Sipariş kalemlerinde tutar, indirim, indirim payı ve KDV kalem bazında
+//     yuvarlanır; sipariş toplamları yuvarlanmış kalem değerlerinin toplamıdır
+//   - Oranlar (KDV, yüzde indirim) yüzde 0,01 hassasiyetle, miktarlar 0,001
+//     hassasiyetle hesaba katılır
+//
+// JSON'da sayı olarak yazılır (örn: 1234.5), eski float64 kayıtlarla uyumludur;
+// okurken sayı veya metin ("1234.50") kabul edilir ve float'a çevrilmeden ayrıştırılır.
+// ============================================
+
+// Money - Kuruş cinsinden tutar
+type Money int64
+
+const (
+	moneyScale    = 100  // 1 birim = 100 kuruş
+	rateScale     = 100  // Yüzde oranları 0,01 hassasiyetle
+	quantityScale = 1000 // Miktarlar 0,001 hassasiyetle
+)
+
+// MoneyFromFloat - float64 tutarı en yakın kuruşa yuvarlayarak Money'e çevirir
+func MoneyFromFloat(f float64) Money {
+	return Money(math.Round(f * moneyScale))
+}
+
+// ParseMoney - Ondalık metni ("1234.56", "-0.5", "1234,56", "1.234,56") kuruşa çevirir
+// Kuruştan fazla basamak varsa yarım kuruş sıfırdan uzağa yuvarlanır.
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	// Bilimsel gösterim (JSON'da nadiren) için float yoluna düş
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("geçersiz tutar: %q", s)
+		}
+		return MoneyFromFloat(f), nil
+	}
+
+	// Ayırıcılar: ikisi birlikteyse sondaki ondalık, diğeri binliktir ("1.234,56", "1,234.56");
+	// tek türden birden çok ayırıcı binliktir ("1.234.567"); tek ayırıcı ondalıktır
+	raw := s
+	lastDot, lastComma := strings.LastIndexByte(s, '.'), strings.LastIndexByte(s, ',')
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		if lastComma > lastDot {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case strings.Count(s, ".") > 1:
+		s = strings.ReplaceAll(s, ".", "")
+	case strings.Count(s, ",") > 1:
+		s = strings.ReplaceAll(s, ",", "")
+	default:
+		s = strings.Replace(s, ",", ".", 1)
+	}
+	if strings.Count(s, ".") > 1 || strings.Contains(s, ",") {
+		return 0, fmt.Errorf("geçersiz tutar: %q", raw)
+	}
+	if s == "" {
+		return 0, fmt.Errorf("geçersiz tutar")
+	}
+
+	negative := false
+	if s[0] == '-' || s[0] == '+' {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+	if s == "" || s == "." {
+		return 0, fmt.Errorf("geçersiz tutar: %q", raw)
+	}
+
+	intPart, fracPart := s, ""
+	if dot := strings.IndexByte(s, '.'); dot >= 0 {
+		intPart, fracPart = s[:dot], s[dot+1:]
+	}
+	if intPart == "" {
+		intPart = "0"
+	}
+	if strings.ContainsAny(intPart, "+-") {
+		return 0, fmt.Errorf("geçersiz tutar: %q", raw)
+	}
+
+	units, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("geçersiz tutar: %q", s)
+	}
+	for _, c := range fracPart {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("geçersiz tutar: %q", s)
+		}
+	}
+
+	// İlk iki basamak kuruş, üçüncü basamak yuvarlama için
+	padded := fracPart + "000"
+	kurus, _ := strconv.ParseInt(padded[:2], 10, 64)
+	total := units*moneyScale + kurus
+	if padded[2] >= '5' {
+		total++
+	}
+
+	if negative {
+		total = -total
+	}
+	return Money(total), nil
+}
+
+// Float64 - Tutarı float64 olarak döner (gösterim ve eski API'ler için)
+func (m Money) Float64() float64 {
+	return float64(m) / moneyScale
+}
+
+// String - "1234.56" biçiminde metin
+func (m Money) String() string {
+	sign := ""
+	v := int64(m)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, v/moneyScale, v%moneyScale)
+}
+
+// MarshalJSON - Tutarı JSON sayısı olarak yazar
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON - JSON sayısı, metni veya null kabul eder
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*m = 0
+		return nil
+	}
+	if len(data) >= 2 && data[0] == '"' {
+		unquoted, err := strconv.Unquote(string(data))
+		if err != nil {
+			return err
+		}
+		data = []byte(unquoted)
+	}
+
+	parsed, err := ParseMoney(string(data))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// mulDivRound - a × b / c, yarım sıfırdan uzağa yuvarlanır (taşmaya karşı big.Int)
+func mulDivRound(a, b, c int64) int64 {
+	if c == 0 {
+		return 0
+	}
+
+	num := new(big.Int).Mul(big.NewInt(a), big.NewInt(b))
+	den := big.NewInt(c)
+
+	negative := (num.Sign() < 0) != (den.Sign() < 0)
+	num.Abs(num)
+	den.Abs(den)
+
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Mul(rem, big.NewInt(2)).Cmp(den) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+
+	if negative {
+		quo.Neg(quo)
+	}
+	return quo.Int64()
+}
+
+// scaleRate - Yüzde oranını 0,01 hassasiyetli tamsayıya çevirir (18.5 → 1850)
+func scaleRate(rate float64) int64 {
+	return int64(math.Round(rate * rateScale))
+}
+
+// MulQuantity - Birim tutar × miktar
+func (m Money) MulQuantity(quantity float64) Money {
+	q := int64(math.Round(quantity * quantityScale))
+	return Money(mulDivRound(int64(m), q, quantityScale))
+}
+
+// DivQuantity - Toplam tutar ÷ miktar (birim maliyet gibi)
+func (m Money) DivQuantity(quantity float64) Money {
+	q := int64(math.Round(quantity * quantityScale))
+	return Money(mulDivRound(int64(m), quantityScale, q))
+}
+
+// MulFraction - Tutarın part/whole miktar oranı kadarı (ortalama maliyetle kısmi çıkış)
+func (m Money) MulFraction(part, whole float64) Money {
+	p := int64(math.Round(part * quantityScale))
+	w := int64(math.Round(whole * quantityScale))
+	return Money(mulDivRound(int64(m), p, w))
+}
+
+// Percent - Tutarın yüzde rate'i (KDV hariç tutar üzerinden KDV, yüzde indirim)
+func (m Money) Percent(rate float64) Money {
+	return Money(mulDivRound(int64(m), scaleRate(rate), 100*rateScale))
+}
+
+// ExtractTax - KDV dahil tutarı KDV hariç tutar ve KDV olarak ayırır
+func (m Money) ExtractTax(rate float64) (net Money, tax Money) {
+	r := scaleRate(rate)
+	net = Money(mulDivRound(int64(m), 100*rateScale, 100*rateScale+r))
+	return net, m - net
+}
+
+// MulRatio - Tutarı num/den oranında ölçekler (orantılı dağıtım)
+func (m Money) MulRatio(num, den Money) Money {
+	return Money(mulDivRound(int64(m), int64(num), int64(den)))
+}
+
+// Min - İki tutardan küçük olanı
+func (m Money) Min(other Money) Money {
+	if other < m {
+		return other
+	}
+	return m
+}
+
+// exchangeRateScale - Kurlar 0,000001 hassasiyetle
+const exchangeRateScale = 1000000
+
+// MulRate - Tutarı döviz kuruyla çarpar (yabancı para → ana para birimi)
+func (m Money) MulRate(rate float64) Money {
+	r := int64(math.Round(rate * exchangeRateScale))
+	return Money(mulDivRound(int64(m), r, exchangeRateScale))
+}
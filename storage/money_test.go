@@ -0,0 +1,123 @@
+package storage
+
+import "testing"
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "", want: 0},
+		{in: "1234.56", want: 123456},
+		{in: "1234,56", want: 123456},
+		{in: "-0.5", want: -50},
+		{in: "+5", want: 500},
+		{in: " 7 ", want: 700},
+		{in: ",5", want: 50},
+		{in: "1.234,56", want: 123456},
+		{in: "1,234.56", want: 123456},
+		{in: "1.234.567", want: 123456700},
+		{in: "1,234,567", want: 123456700},
+		{in: "1,005", want: 101},
+		{in: "-1,005", want: -101},
+		{in: "1,004", want: 100},
+		{in: "1e2", want: 10000},
+		{in: "+-5,50", wantErr: true},
+		{in: "--5", wantErr: true},
+		{in: "5-", wantErr: true},
+		{in: "1.2,3.4", wantErr: true},
+		{in: "12a", wantErr: true},
+		{in: "1,2x", wantErr: true},
+		{in: "-", wantErr: true},
+		{in: ",", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseMoney(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		in   Money
+		want string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{-5, "-0.05"},
+		{123456, "1234.56"},
+		{-100, "-1.00"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyRounding(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Money
+		want Money
+	}{
+		{"MoneyFromFloat half up", MoneyFromFloat(0.125), 13},
+		{"MoneyFromFloat half down", MoneyFromFloat(-0.125), -13},
+		{"MulQuantity", Money(333).MulQuantity(1.5), 500},
+		{"MulQuantity negative", Money(-333).MulQuantity(1.5), -500},
+		{"MulQuantity fraction", Money(1000).MulQuantity(0.001), 1},
+		{"DivQuantity down", Money(1000).DivQuantity(3), 333},
+		{"DivQuantity up", Money(200).DivQuantity(3), 67},
+		{"DivQuantity zero", Money(200).DivQuantity(0), 0},
+		{"MulFraction", Money(1000).MulFraction(1, 3), 333},
+		{"MulFraction whole", Money(1000).MulFraction(2.5, 2.5), 1000},
+		{"Percent half", Money(1005).Percent(10), 101},
+		{"Percent negative half", Money(-1005).Percent(10), -101},
+		{"Percent fractional rate", Money(10000).Percent(18.5), 1850},
+		{"MulRatio", Money(1000).MulRatio(1, 3), 333},
+		{"MulRate", Money(10000).MulRate(32.123456), 321235},
+		{"MulRate negative", Money(-150).MulRate(0.5), -75},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %d, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestMoneyExtractTax(t *testing.T) {
+	tests := []struct {
+		gross   Money
+		rate    float64
+		wantNet Money
+		wantTax Money
+	}{
+		{12000, 20, 10000, 2000},
+		{1000, 18, 847, 153},
+		{1000, 0, 1000, 0},
+		{-1200, 20, -1000, -200},
+	}
+
+	for _, tt := range tests {
+		net, tax := tt.gross.ExtractTax(tt.rate)
+		if net != tt.wantNet || tax != tt.wantTax {
+			t.Errorf("Money(%d).ExtractTax(%v) = %d, %d, want %d, %d", tt.gross, tt.rate, net, tax, tt.wantNet, tt.wantTax)
+		}
+	}
+}
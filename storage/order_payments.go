@@ -0,0 +1,293 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// ============================================
+// Sipariş Ödemeleri
+// Bir ödeme aynı müşterinin bir veya birden fazla siparişine dağıtılabilir;
+// dağıtılmayan kısım cari hesapta alacak (avans) olarak kalır. Dağıtımlar ana
+// para birimindedir. Siparişin ödenen tutarı dağıtımlardan hesaplanıp siparişte
+// saklanır; sipariş toplamı düşerse fazla dağıtım en yeni ödemeden başlayarak
+// geri alınır, sipariş silinir veya taslağa dönerse dağıtımları kaldırılır.
+// ============================================
+
+// Order payment statuses
+const (
+	PaymentStatusUnpaid  = "unpaid"  // Ödenmedi (veresiye)
+	PaymentStatusPartial = "partial" // Kısmen ödendi
+	PaymentStatusPaid    = "paid"    // Tamamen ödendi
+)
+
+// PaymentAllocation - Part of a payment applied to an order (base currency)
+type PaymentAllocation struct {
+	OrderID    string `json:"order_id"`
+	OrderTitle string `json:"order_title"` // Denormalize
+	Amount     Money  `json:"amount"`
+}
+
+// OrderPayment - A payment allocated to an order
+type OrderPayment struct {
+	PaymentID string    `json:"payment_id"`
+	Date      time.Time `json:"date"`
+	Method    string    `json:"method"`
+	Amount    Money     `json:"amount"` // Allocated to this order (base currency)
+	Note      string    `json:"note"`
+}
+
+// AllocatedAmount - Siparişlere dağıtılan toplam
+func (p *Payment) AllocatedAmount() Money {
+	total := Money(0)
+	for _, a := range p.Allocations {
+		total += a.Amount
+	}
+	return total
+}
+
+// updatePaymentStatus - Kalan tutarı ve ödeme durumunu ödenen tutardan hesaplar
+func (o *Order) updatePaymentStatus() {
+	o.OutstandingAmount = o.BaseGrandTotal - o.PaidAmount
+	switch {
+	case o.PaidAmount <= 0:
+		o.PaymentStatus = PaymentStatusUnpaid
+	case o.OutstandingAmount > 0:
+		o.PaymentStatus = PaymentStatusPartial
+	default:
+		o.PaymentStatus = PaymentStatusPaid
+	}
+}
+
+// prepareAllocations - Dağıtımları doğrular: aynı müşterinin siparişleri, ödeme ve sipariş
+// tutarlarını aşmayan pozitif tutarlar. Aynı siparişe ait satırlar birleştirilir.
+func (s *BleveStore) prepareAllocations(payment *Payment) error {
+	var allocations []PaymentAllocation
+	index := make(map[string]int)
+
+	for _, a := range payment.Allocations {
+		if a.OrderID == "" || a.Amount == 0 {
+			continue
+		}
+		if a.Amount < 0 {
+			return fmt.Errorf("dağıtım tutarı negatif olamaz")
+		}
+		if i, ok := index[a.OrderID]; ok {
+			allocations[i].Amount += a.Amount
+			continue
+		}
+		index[a.OrderID] = len(allocations)
+		allocations = append(allocations, a)
+	}
+
+	if total := (&Payment{Allocations: allocations}).AllocatedAmount(); total > payment.BaseAmount {
+		return fmt.Errorf("dağıtılan tutar (%s) ödeme tutarını (%s) aşıyor", total, payment.BaseAmount)
+	}
+
+	for i := range allocations {
+		a := &allocations[i]
+		order, err := s.GetOrder(a.OrderID)
+		if err != nil {
+			return err
+		}
+		if order.CustomerID != payment.CustomerID {
+			return fmt.Errorf("%s: sipariş bu müşteriye ait değil", order.Title)
+		}
+		if order.Status == OrderStatusDraft {
+			return fmt.Errorf("%s: taslak siparişe ödeme dağıtılamaz", order.Title)
+		}
+		a.OrderTitle = order.Title
+
+		other, err := s.allocatedToOrder(order.ID, payment.ID)
+		if err != nil {
+			return err
+		}
+		if open := order.BaseGrandTotal - other; a.Amount > open {
+			return fmt.Errorf("%s: dağıtılan tutar (%s) kalan tutarı (%s) aşıyor", order.Title, a.Amount, open)
+		}
+	}
+
+	payment.Allocations = allocations
+	return nil
+}
+
+// allocatedToOrder - Ödemelerden siparişe dağıtılan toplam (excludePaymentID hariç)
+func (s *BleveStore) allocatedToOrder(orderID, excludePaymentID string) (Money, error) {
+	payments, err := s.ListPayments("", time.Time{}, time.Time{})
+	if err != nil {
+		return 0, err
+	}
+
+	total := Money(0)
+	for _, payment := range payments {
+		if payment.ID == excludePaymentID {
+			continue
+		}
+		for _, a := range payment.Allocations {
+			if a.OrderID == orderID {
+				total += a.Amount
+			}
+		}
+	}
+	return total, nil
+}
+
+// applyOrderPayments - Siparişin ödenen tutarını dağıtımlardan hesaplar
+// Dağıtımlar sipariş toplamını aşıyorsa (veya ödeme başka müşteriye aitse) fazlası
+// en yeni ödemeden başlayarak geri alınır; taslak siparişin dağıtımları tümüyle
+// serbest kalır. Hiçbir şey yazılmaz: sipariş alanları güncellenir, dağıtımı değişen
+// ödemeler döner ve sipariş yazıldıktan sonra savePayments ile kaydedilmelidir.
+func (s *BleveStore) applyOrderPayments(order *Order) ([]*Payment, error) {
+	payments, err := s.ListPayments("", time.Time{}, time.Time{}) // En yeni önce
+	if err != nil {
+		return nil, err
+	}
+
+	// Eskiden yeniye sığan dağıtımlar korunur
+	limit := order.BaseGrandTotal
+	if order.Status == OrderStatusDraft {
+		limit = 0
+	}
+	paid := Money(0)
+	var changedPayments []*Payment
+	for i := len(payments) - 1; i >= 0; i-- {
+		payment := payments[i]
+		changed := false
+		var kept []PaymentAllocation
+		for _, a := range payment.Allocations {
+			if a.OrderID != order.ID {
+				kept = append(kept, a)
+				continue
+			}
+			amount := Money(0)
+			if payment.CustomerID == order.CustomerID {
+				amount = a.Amount.Min(limit - paid)
+			}
+			if amount != a.Amount || a.OrderTitle != order.Title {
+				changed = true
+			}
+			if amount <= 0 {
+				continue
+			}
+			a.Amount = amount
+			a.OrderTitle = order.Title
+			paid += amount
+			kept = append(kept, a)
+		}
+		if changed {
+			payment.Allocations = kept
+			payment.UpdatedAt = time.Now()
+			changedPayments = append(changedPayments, payment)
+		}
+	}
+
+	order.PaidAmount = paid
+	order.updatePaymentStatus()
+	return changedPayments, nil
+}
+
+// savePayments - applyOrderPayments'ın değiştirdiği ödemeleri kaydeder
+func (s *BleveStore) savePayments(payments []*Payment) error {
+	for _, payment := range payments {
+		if err := s.writeJSONFile(paymentsDir, payment.ID, payment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeOrderAllocations - Silinen siparişin dağıtımlarını ödemelerden kaldırır
+// Serbest kalan tutar ödemede dağıtılmamış (avans) olarak kalır.
+func (s *BleveStore) removeOrderAllocations(orderID string) error {
+	payments, err := s.ListPayments("", time.Time{}, time.Time{})
+	if err != nil {
+		return err
+	}
+
+	for _, payment := range payments {
+		var kept []PaymentAllocation
+		for _, a := range payment.Allocations {
+			if a.OrderID != orderID {
+				kept = append(kept, a)
+			}
+		}
+		if len(kept) == len(payment.Allocations) {
+			continue
+		}
+		payment.Allocations = kept
+		payment.UpdatedAt = time.Now()
+		if err := s.writeJSONFile(paymentsDir, payment.ID, payment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshOrderPayments - Verilen siparişlerin ödenen tutarlarını yeniden hesaplayıp kaydeder
+func (s *BleveStore) refreshOrderPayments(orderIDs ...string) error {
+	seen := make(map[string]bool)
+	for _, id := range orderIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		order, err := s.GetOrder(id)
+		if err != nil {
+			continue // Silinmiş sipariş
+		}
+		paid := order.PaidAmount
+		trimmed, err := s.applyOrderPayments(order)
+		if err != nil {
+			return err
+		}
+		if order.PaidAmount != paid {
+			if err := s.writeOrder(order); err != nil {
+				return err
+			}
+		}
+		if err := s.savePayments(trimmed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allocationOrderIDs - Ödemelerin dağıtıldığı sipariş ID'leri
+func allocationOrderIDs(payments ...*Payment) []string {
+	var ids []string
+	for _, payment := range payments {
+		if payment == nil {
+			continue
+		}
+		for _, a := range payment.Allocations {
+			ids = append(ids, a.OrderID)
+		}
+	}
+	return ids
+}
+
+// GetOrderPayments - Payments allocated to an order, newest first
+func (s *BleveStore) GetOrderPayments(orderID string) ([]*OrderPayment, error) {
+	payments, err := s.ListPayments("", time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*OrderPayment{}
+	for _, payment := range payments {
+		for _, a := range payment.Allocations {
+			if a.OrderID != orderID {
+				continue
+			}
+			result = append(result, &OrderPayment{
+				PaymentID: payment.ID,
+				Date:      payment.Date,
+				Method:    payment.Method,
+				Amount:    a.Amount,
+				Note:      payment.Note,
+			})
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ============================================
+// Kâr / Zarar
+// Gelir: satış sayılan siparişlerin KDV hariç, indirimler düşülmüş tutarı (ana
+// para birimi, satış tarihine göre). Satılan malın maliyeti: stok çıkışlarının
+// değerleme yöntemine göre maliyeti (GetCostOfGoodsSold ile aynı hesap).
+// Giderler: masraflar (ödeme yönteminden bağımsız). Sütunlar takvim aylarıdır.
+// ============================================
+
+// ProfitLossColumn - Profit and loss of one month (or the period total)
+type ProfitLossColumn struct {
+	Period             string           `json:"period"` // "2006-01" or "total"
+	Revenue            Money            `json:"revenue"`
+	OrderCount         int              `json:"order_count"`
+	COGS               Money            `json:"cogs"`
+	GrossProfit        Money            `json:"gross_profit"`
+	GrossMarginPercent float64          `json:"gross_margin_percent"`
+	Expenses           Money            `json:"expenses"`
+	ExpensesByCategory map[string]Money `json:"expenses_by_category"`
+	NetProfit          Money            `json:"net_profit"`
+	NetMarginPercent   float64          `json:"net_margin_percent"`
+}
+
+// ProfitLossReport - Month-by-month profit and loss for a period
+type ProfitLossReport struct {
+	StartDate  string              `json:"start_date"`
+	EndDate    string              `json:"end_date"`
+	Currency   string              `json:"currency"`
+	Method     string              `json:"method"`     // Valuation method used for COGS
+	Categories []string            `json:"categories"` // Expense categories, largest first
+	Months     []*ProfitLossColumn `json:"months"`
+	Total      *ProfitLossColumn   `json:"total"`
+}
+
+// newProfitLossColumn - Boş sütun
+func newProfitLossColumn(period string) *ProfitLossColumn {
+	return &ProfitLossColumn{Period: period, ExpensesByCategory: make(map[string]Money)}
+}
+
+// finish - Kâr ve marjları hesaplar
+func (c *ProfitLossColumn) finish() {
+	c.GrossProfit = c.Revenue - c.COGS
+	c.GrossMarginPercent = marginPercent(c.GrossProfit, c.Revenue)
+	c.NetProfit = c.GrossProfit - c.Expenses
+	c.NetMarginPercent = marginPercent(c.NetProfit, c.Revenue)
+}
+
+// GetProfitLossReport - Revenue, cost of goods sold and expenses by month between start and end (inclusive)
+func (s *BleveStore) GetProfitLossReport(start, end time.Time, method string) (*ProfitLossReport, error) {
+	if end.Before(start) {
+		return nil, fmt.Errorf("end date is before start date")
+	}
+	method = normalizeValuationMethod(method)
+
+	report := &ProfitLossReport{
+		StartDate:  start.Format("2006-01-02"),
+		EndDate:    end.Format("2006-01-02"),
+		Currency:   GetBaseCurrency(),
+		Method:     method,
+		Categories: []string{},
+		Months:     []*ProfitLossColumn{},
+		Total:      newProfitLossColumn("total"),
+	}
+
+	// Aralıktaki her ay için bir sütun
+	months := make(map[string]*ProfitLossColumn)
+	for m := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.Local); !m.After(end); m = m.AddDate(0, 1, 0) {
+		column := newProfitLossColumn(m.Format("2006-01"))
+		months[column.Period] = column
+		report.Months = append(report.Months, column)
+	}
+	column := func(t time.Time) *ProfitLossColumn {
+		if t.Before(start) || t.After(end) {
+			return nil
+		}
+		return months[t.In(time.Local).Format("2006-01")]
+	}
+
+	// Gelir
+	orders, err := s.ListOrders()
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if !order.isSale() {
+			continue
+		}
+		c := column(order.saleDate())
+		if c == nil {
+			continue
+		}
+		c.Revenue += order.BaseSubtotal
+		c.OrderCount++
+	}
+
+	// Satılan malın maliyeti
+	if _, err := s.replayValuation(method, end, func(m *StockMovement, cost Money) {
+		if c := column(m.Date); c != nil {
+			c.COGS += cost
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	// Giderler
+	expenses, err := s.ListExpenses("", start, end)
+	if err != nil {
+		return nil, err
+	}
+	categoryTotals := make(map[string]Money)
+	for _, expense := range expenses {
+		c := column(expense.Date)
+		if c == nil {
+			continue
+		}
+		c.Expenses += expense.BaseAmount
+		c.ExpensesByCategory[expense.Category] += expense.BaseAmount
+		categoryTotals[expense.Category] += expense.BaseAmount
+	}
+
+	// Toplam sütun
+	total := report.Total
+	for _, c := range report.Months {
+		c.finish()
+		total.Revenue += c.Revenue
+		total.OrderCount += c.OrderCount
+		total.COGS += c.COGS
+		total.Expenses += c.Expenses
+		for category, amount := range c.ExpensesByCategory {
+			total.ExpensesByCategory[category] += amount
+		}
+	}
+	total.finish()
+
+	for category := range categoryTotals {
+		report.Categories = append(report.Categories, category)
+	}
+	sort.Slice(report.Categories, func(i, j int) bool {
+		a, b := report.Categories[i], report.Categories[j]
+		if categoryTotals[a] != categoryTotals[b] {
+			return categoryTotals[a] > categoryTotals[b]
+		}
+		return a < b
+	})
+
+	return report, nil
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ============================================
+// Profitability - Kârlılık raporu
+// Gelir: kalemlerin KDV hariç, tüm indirimler düşülmüş tutarı (sipariş tarihindeki kurla
+// ana para birimine çevrilir). Maliyet: kaleme satış anında yazılan birim maliyet × miktar.
+// ============================================
+
+// ProfitLine - Revenue, cost and profit of one order, customer or product
+type ProfitLine struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Date          string  `json:"date,omitempty"`     // Orders only
+	Quantity      float64 `json:"quantity,omitempty"` // Products only
+	Revenue       Money   `json:"revenue"`
+	Cost          Money   `json:"cost"`
+	Profit        Money   `json:"profit"`
+	MarginPercent float64 `json:"margin_percent"`
+	MissingCost   int     `json:"missing_cost"` // Items sold without a known cost
+}
+
+// ProfitabilityReport - Profitability per order, customer and product for a period
+type ProfitabilityReport struct {
+	StartDate string        `json:"start_date"`
+	EndDate   string        `json:"end_date"`
+	Currency  string        `json:"currency"` // Base currency
+	Total     ProfitLine    `json:"total"`
+	Orders    []*ProfitLine `json:"orders"`
+	Customers []*ProfitLine `json:"customers"`
+	Products  []*ProfitLine `json:"products"`
+	Warnings  []string      `json:"warnings"`
+}
+
+// marginPercent - Profit / revenue × 100, rounded to 2 decimals (0 without revenue)
+func marginPercent(profit, revenue Money) float64 {
+	if revenue == 0 {
+		return 0
+	}
+	return math.Round(float64(profit)*10000/float64(revenue)) / 100
+}
+
+// add - Add one sold item to the line
+func (l *ProfitLine) add(revenue, cost Money, quantity float64, missingCost bool) {
+	l.Revenue += revenue
+	l.Cost += cost
+	l.Quantity += quantity
+	if missingCost {
+		l.MissingCost++
+	}
+}
+
+// finish - Calculate profit and margin
+func (l *ProfitLine) finish() {
+	l.Profit = l.Revenue - l.Cost
+	l.MarginPercent = marginPercent(l.Profit, l.Revenue)
+}
+
+// GetProfitabilityReport - Margin and profit per order, customer and product between start and end
+func (s *BleveStore) GetProfitabilityReport(start, end time.Time) (*ProfitabilityReport, error) {
+	orders, err := s.ListOrders()
+	if err != nil {
+		return nil, err
+	}
+	start = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local)
+	end = time.Date(end.Year(), end.Month(), end.Day(), 23, 59, 59, 999999999, time.Local)
+
+	rates, err := s.loadExchangeRateTable()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ProfitabilityReport{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Currency:  GetBaseCurrency(),
+		Total:     ProfitLine{ID: "total", Name: "Toplam"},
+		Orders:    []*ProfitLine{},
+		Warnings:  []string{},
+	}
+
+	customers := make(map[string]*ProfitLine)
+	products := make(map[string]*ProfitLine)
+
+	for _, order := range orders {
+		// Yalnızca satışlar, satış tarihine göre
+		if !order.isSale() {
+			continue
+		}
+		if date := order.saleDate(); date.Before(start) || date.After(end) {
+			continue
+		}
+
+		rate, err := rates.orderRate(order)
+		if err != nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s: %v", order.ID, err))
+			continue
+		}
+
+		orderLine := &ProfitLine{
+			ID:   order.ID,
+			Name: order.Title,
+			Date: order.saleDate().Format("2006-01-02"),
+		}
+
+		customerKey := order.CustomerID
+		if customerKey == "" {
+			customerKey = "-"
+		}
+		customerLine, ok := customers[customerKey]
+		if !ok {
+			customerLine = &ProfitLine{ID: order.CustomerID, Name: order.CustomerName}
+			customers[customerKey] = customerLine
+		}
+
+		for _, item := range order.Items {
+			revenue := item.NetAmount.MulRate(rate)
+			cost := item.UnitCost.MulQuantity(item.Quantity)
+			missingCost := item.UnitCost == 0 && item.IsPart() // İşçilik / hizmetin maliyeti yok
+
+			productKey := item.ProductID
+			if productKey == "" {
+				productKey = "name:" + item.ProductName
+			}
+			productLine, ok := products[productKey]
+			if !ok {
+				productLine = &ProfitLine{ID: item.ProductID, Name: item.ProductName}
+				products[productKey] = productLine
+			}
+
+			orderLine.add(revenue, cost, 0, missingCost)
+			customerLine.add(revenue, cost, 0, missingCost)
+			productLine.add(revenue, cost, item.StockQuantity(), missingCost)
+			report.Total.add(revenue, cost, 0, missingCost)
+		}
+
+		orderLine.finish()
+		report.Orders = append(report.Orders, orderLine)
+	}
+
+	report.Total.finish()
+	report.Customers = sortedProfitLines(customers)
+	report.Products = sortedProfitLines(products)
+
+	sort.Slice(report.Orders, func(i, j int) bool {
+		return report.Orders[i].Date > report.Orders[j].Date
+	})
+
+	return report, nil
+}
+
+// sortedProfitLines - Finish lines and sort by profit (highest first)
+func sortedProfitLines(lines map[string]*ProfitLine) []*ProfitLine {
+	result := make([]*ProfitLine, 0, len(lines))
+	for _, l := range lines {
+		l.finish()
+		result = append(result, l)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Profit > result[j].Profit
+	})
+	return result
+}
@@ -0,0 +1,498 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ============================================
+// Tedarikçiler ve Satın Alma Siparişleri
+// Tedarikçi, IsSupplier işaretli bir Customer kaydıdır. Satın alma siparişi
+// (PurchaseOrder) tedarikçiden beklenen ürünleri, fiyatları ve tarihleri tutar.
+// Mal kabulü (ReceiveGoods) her satır için siparişe bağlı bir stok girişi yazar;
+// kısmi kabuller biriktirilir, tüm satırlar tamamlanınca sipariş kapanır.
+// ============================================
+
+const purchaseOrdersDir = "purchase_orders"
+
+// Purchase order statuses
+const (
+	PurchaseOrderOpen      = "open"      // Bekleniyor, hiç kabul yok
+	PurchaseOrderPartial   = "partial"   // Kısmen teslim alındı
+	PurchaseOrderClosed    = "closed"    // Tamamı alındı veya eksik kapatıldı
+	PurchaseOrderCancelled = "cancelled" // Hiç kabul yapılmadan iptal edildi
+)
+
+// PurchaseOrderItem - Expected product line of a purchase order
+type PurchaseOrderItem struct {
+	ID               string  `json:"id"`
+	ProductID        string  `json:"product_id"`
+	ProductName      string  `json:"product_name"`
+	Unit             string  `json:"unit"`              // Order unit (empty = product purchase unit, then base unit)
+	Quantity         float64 `json:"quantity"`          // Ordered, in Unit
+	ReceivedQuantity float64 `json:"received_quantity"` // Received so far, in Unit
+	UnitCost         Money   `json:"unit_cost"`         // Agreed price per Unit, in the order currency
+	Total            Money   `json:"total"`             // Quantity × UnitCost
+}
+
+// Remaining - Henüz teslim alınmamış miktar
+func (item *PurchaseOrderItem) Remaining() float64 {
+	return math.Max(0, math.Round((item.Quantity-item.ReceivedQuantity)*quantityScale)/quantityScale)
+}
+
+// GoodsReceiptLine - Quantity received for one purchase order line
+type GoodsReceiptLine struct {
+	ItemID        string   `json:"item_id"`
+	Quantity      float64  `json:"quantity"` // In the item's unit
+	LotNumber     string   `json:"lot_number,omitempty"`
+	ExpiryDate    string   `json:"expiry_date,omitempty"` // "2006-01-02"
+	SerialNumbers []string `json:"serial_numbers,omitempty"`
+	MovementID    string   `json:"movement_id,omitempty"` // Stock-in movement posted for the line
+}
+
+// GoodsReceipt - One delivery received against a purchase order
+type GoodsReceipt struct {
+	ID         string             `json:"id"`
+	Date       time.Time          `json:"date"`
+	LocationID string             `json:"location_id"`
+	Note       string             `json:"note"`
+	Lines      []GoodsReceiptLine `json:"lines"`
+}
+
+// PurchaseOrder - Order placed with a supplier
+type PurchaseOrder struct {
+	ID           string              `json:"id"`
+	Number       string              `json:"number"` // SA-0001
+	SupplierID   string              `json:"supplier_id"`
+	SupplierName string              `json:"supplier_name"` // Denormalize
+	Currency     string              `json:"currency"`      // Price currency (empty = base)
+	Items        []PurchaseOrderItem `json:"items"`
+	Total        Money               `json:"total"` // Total of the lines, in Currency
+	Status       string              `json:"status"`
+	Note         string              `json:"note"`
+
+	OrderDate    time.Time      `json:"order_date"`
+	ExpectedDate *time.Time     `json:"expected_date,omitempty"` // Expected delivery
+	Receipts     []GoodsReceipt `json:"receipts"`
+	ClosedAt     *time.Time     `json:"closed_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsOpen - Sipariş hâlâ mal bekliyor mu
+func (po *PurchaseOrder) IsOpen() bool {
+	return po.Status == PurchaseOrderOpen || po.Status == PurchaseOrderPartial
+}
+
+// updateStatus - Kabul edilen miktarlara göre durumu günceller; tamamlanınca kapatır
+func (po *PurchaseOrder) updateStatus() {
+	if !po.IsOpen() {
+		return
+	}
+
+	received, complete := false, true
+	for i := range po.Items {
+		if po.Items[i].ReceivedQuantity > 0 {
+			received = true
+		}
+		if po.Items[i].Remaining() >= stockTolerance {
+			complete = false
+		}
+	}
+
+	switch {
+	case complete:
+		now := time.Now()
+		po.Status = PurchaseOrderClosed
+		po.ClosedAt = &now
+	case received:
+		po.Status = PurchaseOrderPartial
+	default:
+		po.Status = PurchaseOrderOpen
+	}
+}
+
+// SetCustomerSupplier - Marks a party as supplier (or removes the role)
+func (s *BleveStore) SetCustomerSupplier(id string, isSupplier bool) error {
+	customer, err := s.GetCustomer(id)
+	if err != nil {
+		return err
+	}
+
+	customer.IsSupplier = isSupplier
+	return s.SaveCustomer(customer)
+}
+
+// ListSuppliers - Parties with the supplier role, by name
+func (s *BleveStore) ListSuppliers() ([]*Customer, error) {
+	customers, err := s.ListCustomers()
+	if err != nil {
+		return nil, err
+	}
+
+	suppliers := []*Customer{}
+	for _, c := range customers {
+		if c.IsSupplier {
+			suppliers = append(suppliers, c)
+		}
+	}
+	sort.Slice(suppliers, func(i, j int) bool {
+		return suppliers[i].Name < suppliers[j].Name
+	})
+
+	return suppliers, nil
+}
+
+// nextPurchaseOrderNumber - Sıradaki sipariş numarası (SA-0001, SA-0002, ...)
+func (s *BleveStore) nextPurchaseOrderNumber() (string, error) {
+	orders, err := s.ListPurchaseOrders("", "")
+	if err != nil {
+		return "", err
+	}
+
+	last := 0
+	for _, po := range orders {
+		var n int
+		if _, err := fmt.Sscanf(po.Number, "SA-%d", &n); err == nil && n > last {
+			last = n
+		}
+	}
+	return fmt.Sprintf("SA-%04d", last+1), nil
+}
+
+// preparePurchaseOrder - Tedarikçiyi ve satırları doğrular, birimleri ve toplamları atar
+// existing verilirse satırlar kabul edilen miktarlarını korur.
+func (s *BleveStore) preparePurchaseOrder(po *PurchaseOrder, existing *PurchaseOrder) error {
+	supplier, err := s.GetCustomer(po.SupplierID)
+	if err != nil {
+		return fmt.Errorf("tedarikçi bulunamadı: %w", err)
+	}
+	if !supplier.IsSupplier {
+		return fmt.Errorf("%s tedarikçi olarak işaretli değil", supplier.Name)
+	}
+	po.SupplierName = supplier.Name
+	if po.Currency, err = normalizeCurrency(po.Currency); err != nil {
+		return err
+	}
+
+	if len(po.Items) == 0 {
+		return fmt.Errorf("satın alma siparişinde en az bir kalem olmalı")
+	}
+
+	received := make(map[string]*PurchaseOrderItem)
+	if existing != nil {
+		for i := range existing.Items {
+			received[existing.Items[i].ID] = &existing.Items[i]
+		}
+	}
+
+	po.Total = 0
+	for i := range po.Items {
+		item := &po.Items[i]
+		if item.ID == "" {
+			item.ID = uuid.New().String()
+		}
+
+		product, err := s.GetProduct(item.ProductID)
+		if err != nil {
+			return fmt.Errorf("ürün bulunamadı: %s", item.ProductID)
+		}
+		item.ProductName = product.Name
+		if item.Unit == "" {
+			item.Unit = product.PurchaseUnit
+		}
+		if item.Unit == "" {
+			item.Unit = product.Unit
+		}
+		if _, ok := product.UnitFactor(item.Unit); !ok {
+			return fmt.Errorf("%s: %q biriminden %q birimine dönüşüm tanımlı değil", product.Name, item.Unit, product.Unit)
+		}
+		if err := ValidateQuantity(item.Quantity, item.Unit); err != nil {
+			return fmt.Errorf("%s: %w", product.Name, err)
+		}
+		if item.UnitCost < 0 {
+			return fmt.Errorf("%s: birim fiyat negatif olamaz", product.Name)
+		}
+
+		item.ReceivedQuantity = 0
+		if old, ok := received[item.ID]; ok {
+			if old.ReceivedQuantity > 0 && (old.ProductID != item.ProductID || old.Unit != item.Unit) {
+				return fmt.Errorf("%s: teslim alınmış kalemin ürünü veya birimi değiştirilemez", product.Name)
+			}
+			if item.Quantity < old.ReceivedQuantity-stockTolerance {
+				return fmt.Errorf("%s: miktar teslim alınan miktarın (%.2f) altına indirilemez", product.Name, old.ReceivedQuantity)
+			}
+			item.ReceivedQuantity = old.ReceivedQuantity
+			delete(received, item.ID)
+		}
+
+		item.Total = item.UnitCost.MulQuantity(item.Quantity)
+		po.Total += item.Total
+	}
+
+	// Teslim alınmış kalemler silinemez
+	for _, old := range received {
+		if old.ReceivedQuantity > 0 {
+			return fmt.Errorf("%s: teslim alınmış kalem silinemez", old.ProductName)
+		}
+	}
+
+	return nil
+}
+
+// CreatePurchaseOrder - Creates a purchase order for a supplier
+func (s *BleveStore) CreatePurchaseOrder(po *PurchaseOrder) (*PurchaseOrder, error) {
+	if err := s.preparePurchaseOrder(po, nil); err != nil {
+		return nil, err
+	}
+
+	number, err := s.nextPurchaseOrderNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	po.ID = uuid.New().String()
+	po.Number = number
+	po.Status = PurchaseOrderOpen
+	po.Receipts = []GoodsReceipt{}
+	po.ClosedAt = nil
+	if po.OrderDate.IsZero() {
+		po.OrderDate = now
+	}
+	po.CreatedAt = now
+	po.UpdatedAt = now
+
+	if err := s.writeJSONFile(purchaseOrdersDir, po.ID, po); err != nil {
+		return nil, err
+	}
+	return po, nil
+}
+
+// UpdatePurchaseOrder - Updates the supplier, lines, prices and dates of an open purchase order
+// Received quantities are kept; a received line cannot be removed or reduced below what was received.
+func (s *BleveStore) UpdatePurchaseOrder(po *PurchaseOrder) (*PurchaseOrder, error) {
+	existing, err := s.GetPurchaseOrder(po.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !existing.IsOpen() {
+		return nil, fmt.Errorf("kapalı satın alma siparişi değiştirilemez")
+	}
+	if len(existing.Receipts) > 0 && po.SupplierID != existing.SupplierID {
+		return nil, fmt.Errorf("mal kabulü yapılmış siparişin tedarikçisi değiştirilemez")
+	}
+
+	if err := s.preparePurchaseOrder(po, existing); err != nil {
+		return nil, err
+	}
+
+	po.Number = existing.Number
+	po.Status = existing.Status
+	po.Receipts = existing.Receipts
+	po.ClosedAt = nil
+	po.CreatedAt = existing.CreatedAt
+	po.UpdatedAt = time.Now()
+	if po.OrderDate.IsZero() {
+		po.OrderDate = existing.OrderDate
+	}
+	po.updateStatus()
+
+	if err := s.writeJSONFile(purchaseOrdersDir, po.ID, po); err != nil {
+		return nil, err
+	}
+	return po, nil
+}
+
+// GetPurchaseOrder - Returns a purchase order by ID
+func (s *BleveStore) GetPurchaseOrder(id string) (*PurchaseOrder, error) {
+	var po PurchaseOrder
+	if err := s.readJSONFile(purchaseOrdersDir, id, &po); err != nil {
+		return nil, fmt.Errorf("satın alma siparişi bulunamadı: %s", id)
+	}
+	return &po, nil
+}
+
+// ListPurchaseOrders - Purchase orders, newest first; filters are optional
+// status "open" also matches partially received orders.
+func (s *BleveStore) ListPurchaseOrders(supplierID, status string) ([]*PurchaseOrder, error) {
+	ids, err := s.listJSONFileIDs(purchaseOrdersDir)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := []*PurchaseOrder{}
+	for _, id := range ids {
+		var po PurchaseOrder
+		if err := s.readJSONFile(purchaseOrdersDir, id, &po); err != nil {
+			continue
+		}
+		if supplierID != "" && po.SupplierID != supplierID {
+			continue
+		}
+		if status == PurchaseOrderOpen && !po.IsOpen() {
+			continue
+		}
+		if status != "" && status != PurchaseOrderOpen && po.Status != status {
+			continue
+		}
+		orders = append(orders, &po)
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].OrderDate.After(orders[j].OrderDate)
+	})
+
+	return orders, nil
+}
+
+// ClosePurchaseOrder - Closes an open purchase order without waiting for the rest
+// An order with no receipts is marked cancelled instead.
+func (s *BleveStore) ClosePurchaseOrder(id, note string) (*PurchaseOrder, error) {
+	po, err := s.GetPurchaseOrder(id)
+	if err != nil {
+		return nil, err
+	}
+	if !po.IsOpen() {
+		return nil, fmt.Errorf("satın alma siparişi zaten kapalı")
+	}
+
+	now := time.Now()
+	po.Status = PurchaseOrderClosed
+	if len(po.Receipts) == 0 {
+		po.Status = PurchaseOrderCancelled
+	}
+	po.ClosedAt = &now
+	if note = strings.TrimSpace(note); note != "" {
+		if po.Note != "" {
+			po.Note += "\n"
+		}
+		po.Note += note
+	}
+	po.UpdatedAt = now
+
+	if err := s.writeJSONFile(purchaseOrdersDir, po.ID, po); err != nil {
+		return nil, err
+	}
+	return po, nil
+}
+
+// DeletePurchaseOrder - Deletes a purchase order that has no goods receipts
+func (s *BleveStore) DeletePurchaseOrder(id string) error {
+	po, err := s.GetPurchaseOrder(id)
+	if err != nil {
+		return err
+	}
+	if len(po.Receipts) > 0 {
+		return fmt.Errorf("mal kabulü yapılmış satın alma siparişi silinemez; kapatın")
+	}
+	return s.removeJSONFile(purchaseOrdersDir, id)
+}
+
+// ReceiveGoods - Receives a delivery against a purchase order
+// Every line posts a stock-in at the agreed price (in the order's unit and currency) linked to
+// the purchase order. A line may not exceed what is still expected. Lines already posted stay
+// recorded if a later line fails; the error names the failed lines. The order closes
+// automatically once every line is fully received.
+func (s *BleveStore) ReceiveGoods(purchaseOrderID string, receipt GoodsReceipt) (*PurchaseOrder, error) {
+	po, err := s.GetPurchaseOrder(purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	if !po.IsOpen() {
+		return nil, fmt.Errorf("kapalı satın alma siparişine mal kabulü yapılamaz")
+	}
+
+	locationID, err := s.resolveLocation(receipt.LocationID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make(map[string]*PurchaseOrderItem)
+	for i := range po.Items {
+		items[po.Items[i].ID] = &po.Items[i]
+	}
+
+	// Önce tüm satırları doğrula
+	wanted := make(map[string]float64)
+	for _, line := range receipt.Lines {
+		item, ok := items[line.ItemID]
+		if !ok {
+			return nil, fmt.Errorf("satın alma siparişinde olmayan kalem: %s", line.ItemID)
+		}
+		if err := ValidateQuantity(line.Quantity, item.Unit); err != nil {
+			return nil, fmt.Errorf("%s: %w", item.ProductName, err)
+		}
+		wanted[line.ItemID] += line.Quantity
+		if wanted[line.ItemID] > item.Remaining()+stockTolerance {
+			return nil, fmt.Errorf("%s: beklenen miktardan fazla (kalan %.2f %s)", item.ProductName, item.Remaining(), item.Unit)
+		}
+	}
+	if len(wanted) == 0 {
+		return nil, fmt.Errorf("mal kabulünde en az bir satır olmalı")
+	}
+
+	note := "Satın alma: " + po.Number
+	if po.SupplierName != "" {
+		note += " - " + po.SupplierName
+	}
+	if receipt.Note != "" {
+		note += " (" + receipt.Note + ")"
+	}
+
+	receipt.ID = uuid.New().String()
+	receipt.Date = time.Now()
+	receipt.LocationID = locationID
+
+	var posted []GoodsReceiptLine
+	var errs []string
+	for _, line := range receipt.Lines {
+		item := items[line.ItemID]
+		movement, err := s.receiveStock(BulkStockInfo{
+			ProductID:       item.ProductID,
+			Amount:          line.Quantity,
+			Unit:            item.Unit,
+			Note:            note,
+			UnitCost:        item.UnitCost,
+			Currency:        po.Currency,
+			LocationID:      locationID,
+			LotNumber:       line.LotNumber,
+			ExpiryDate:      line.ExpiryDate,
+			SerialNumbers:   line.SerialNumbers,
+			PurchaseOrderID: po.ID,
+		})
+		if movement == nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", item.ProductName, err))
+			continue
+		}
+		// Hareket yazıldı; seri güncellemesi hatası olsa da miktar teslim alınmıştır
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", item.ProductName, err))
+		}
+
+		line.MovementID = movement.ID
+		item.ReceivedQuantity = math.Round((item.ReceivedQuantity+line.Quantity)*quantityScale) / quantityScale
+		posted = append(posted, line)
+	}
+
+	if len(posted) > 0 {
+		receipt.Lines = posted
+		po.Receipts = append(po.Receipts, receipt)
+		po.updateStatus()
+		po.UpdatedAt = time.Now()
+		if err := s.writeJSONFile(purchaseOrdersDir, po.ID, po); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(errs) > 0 {
+		return po, fmt.Errorf("mal kabulü tamamlanamadı: %s", strings.Join(errs, "; "))
+	}
+	return po, nil
+}
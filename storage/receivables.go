@@ -0,0 +1,492 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ============================================
+// Cari Hesap (Müşteri Alacakları)
+// Müşterinin cari hesabı siparişleri borç, ödemeleri alacak olarak ana para
+// biriminde birleştirir. Taslak ve onaylı (teslim bekleyen) siparişler henüz
+// satış olmadığından cariye girmez. Yaşlandırmada ödemeler en eski siparişten
+// başlayarak kapatılır.
+// ============================================
+
+const paymentsDir = "payments"
+
+// Payment methods
+const (
+	PaymentMethodCash     = "cash"     // Nakit
+	PaymentMethodCard     = "card"     // Kredi / banka kartı
+	PaymentMethodTransfer = "transfer" // Havale / EFT
+)
+
+// Ledger entry types
+const (
+	LedgerEntryOrder   = "order"
+	LedgerEntryPayment = "payment"
+)
+
+// Payment - Payment received from a customer
+type Payment struct {
+	ID           string    `json:"id"`
+	CustomerID   string    `json:"customer_id"`
+	CustomerName string    `json:"customer_name"` // Denormalize
+	Date         time.Time `json:"date"`
+	Method       string    `json:"method"`        // cash, card, transfer
+	Amount       Money     `json:"amount"`        // In Currency
+	Currency     string    `json:"currency"`      // Empty = base currency
+	ExchangeRate float64   `json:"exchange_rate"` // Rate on Date
+	BaseAmount   Money     `json:"base_amount"`   // Amount in the base currency
+	Note         string    `json:"note"`
+
+	Allocations       []PaymentAllocation `json:"allocations"`        // Parts applied to orders
+	UnallocatedAmount Money               `json:"unallocated_amount"` // BaseAmount - allocations (on account)
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// LedgerEntry - One line of a customer ledger (amounts in the base currency)
+type LedgerEntry struct {
+	Date        time.Time `json:"date"`
+	Type        string    `json:"type"`         // order or payment
+	ReferenceID string    `json:"reference_id"` // Order or payment ID
+	Description string    `json:"description"`
+	Debit       Money     `json:"debit"`   // Order total
+	Credit      Money     `json:"credit"`  // Payment
+	Balance     Money     `json:"balance"` // Running balance (positive = customer owes)
+}
+
+// CustomerStatement - Customer ledger for a period with opening and closing balance
+type CustomerStatement struct {
+	CustomerID     string         `json:"customer_id"`
+	CustomerName   string         `json:"customer_name"`
+	Phone          string         `json:"phone"`
+	StartDate      string         `json:"start_date"` // Empty = from the first entry
+	EndDate        string         `json:"end_date"`
+	Currency       string         `json:"currency"`
+	OpeningBalance Money          `json:"opening_balance"`
+	Entries        []*LedgerEntry `json:"entries"`
+	TotalDebit     Money          `json:"total_debit"`
+	TotalCredit    Money          `json:"total_credit"`
+	ClosingBalance Money          `json:"closing_balance"`
+}
+
+// AgedReceivable - A customer's open balance by age of the unpaid orders
+type AgedReceivable struct {
+	CustomerID   string `json:"customer_id"`
+	CustomerName string `json:"customer_name"`
+	Phone        string `json:"phone"`
+	Current      Money  `json:"current"` // 0-30 days
+	Days31To60   Money  `json:"days_31_60"`
+	Days61To90   Money  `json:"days_61_90"`
+	Over90       Money  `json:"over_90"`
+	Total        Money  `json:"total"`  // Open balance
+	Credit       Money  `json:"credit"` // Unused payments (customer is in credit)
+}
+
+// add - Tutarı yaşına göre dilime ekler
+func (a *AgedReceivable) add(amount Money, days int) {
+	switch {
+	case days <= 30:
+		a.Current += amount
+	case days <= 60:
+		a.Days31To60 += amount
+	case days <= 90:
+		a.Days61To90 += amount
+	default:
+		a.Over90 += amount
+	}
+	a.Total += amount
+}
+
+// AgedReceivablesReport - Aged receivables of all customers with an open balance
+type AgedReceivablesReport struct {
+	Date      string            `json:"date"`
+	Currency  string            `json:"currency"`
+	Customers []*AgedReceivable `json:"customers"`
+	Totals    AgedReceivable    `json:"totals"`
+}
+
+// isValidPaymentMethod - Ödeme yöntemi geçerli mi
+func isValidPaymentMethod(method string) bool {
+	switch method {
+	case PaymentMethodCash, PaymentMethodCard, PaymentMethodTransfer:
+		return true
+	}
+	return false
+}
+
+// preparePayment - Müşteriyi, yöntemi ve tutarı doğrular; ana para birimi tutarını hesaplar
+func (s *BleveStore) preparePayment(payment *Payment) error {
+	customer, err := s.GetCustomer(payment.CustomerID)
+	if err != nil {
+		return err
+	}
+	payment.CustomerName = customer.Name
+
+	if !isValidPaymentMethod(payment.Method) {
+		return fmt.Errorf("geçersiz ödeme yöntemi: %q", payment.Method)
+	}
+	if payment.Amount <= 0 {
+		return fmt.Errorf("ödeme tutarı sıfırdan büyük olmalı")
+	}
+	if payment.Date.IsZero() {
+		payment.Date = time.Now()
+	}
+	if payment.Method == PaymentMethodCash {
+		if err := s.checkCashDayOpen(payment.Date); err != nil {
+			return err
+		}
+	}
+	payment.Note = strings.TrimSpace(payment.Note)
+
+	currency, err := normalizeCurrency(payment.Currency)
+	if err != nil {
+		return err
+	}
+	payment.Currency = currency
+	baseAmount, rate, err := s.ConvertToBase(payment.Amount, payment.Currency, payment.Date)
+	if err != nil {
+		return err
+	}
+	payment.BaseAmount = baseAmount
+	payment.ExchangeRate = rate
+
+	if err := s.prepareAllocations(payment); err != nil {
+		return err
+	}
+	payment.UnallocatedAmount = payment.BaseAmount - payment.AllocatedAmount()
+
+	return nil
+}
+
+// CreatePayment - Records a payment received from a customer
+func (s *BleveStore) CreatePayment(payment *Payment) (*Payment, error) {
+	if err := s.preparePayment(payment); err != nil {
+		return nil, err
+	}
+
+	payment.ID = uuid.New().String()
+	payment.CreatedAt = time.Now()
+	payment.UpdatedAt = payment.CreatedAt
+
+	if err := s.writeJSONFile(paymentsDir, payment.ID, payment); err != nil {
+		return nil, err
+	}
+	if err := s.refreshOrderPayments(allocationOrderIDs(payment)...); err != nil {
+		return payment, err
+	}
+	if err := s.UpdateCustomerStats(payment.CustomerID); err != nil {
+		return payment, err
+	}
+	return payment, nil
+}
+
+// UpdatePayment - Updates a payment's date, method, amount or note
+func (s *BleveStore) UpdatePayment(payment *Payment) (*Payment, error) {
+	existing, err := s.GetPayment(payment.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.Method == PaymentMethodCash {
+		if err := s.checkCashDayOpen(existing.Date); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.preparePayment(payment); err != nil {
+		return nil, err
+	}
+	payment.CreatedAt = existing.CreatedAt
+	payment.UpdatedAt = time.Now()
+
+	if err := s.writeJSONFile(paymentsDir, payment.ID, payment); err != nil {
+		return nil, err
+	}
+	if err := s.refreshOrderPayments(allocationOrderIDs(existing, payment)...); err != nil {
+		return payment, err
+	}
+
+	if existing.CustomerID != payment.CustomerID {
+		s.UpdateCustomerStats(existing.CustomerID)
+	}
+	if err := s.UpdateCustomerStats(payment.CustomerID); err != nil {
+		return payment, err
+	}
+	return payment, nil
+}
+
+// DeletePayment - Deletes a payment
+func (s *BleveStore) DeletePayment(id string) error {
+	payment, err := s.GetPayment(id)
+	if err != nil {
+		return err
+	}
+	if payment.Method == PaymentMethodCash {
+		if err := s.checkCashDayOpen(payment.Date); err != nil {
+			return err
+		}
+	}
+	if err := s.removeJSONFile(paymentsDir, id); err != nil {
+		return err
+	}
+	if err := s.refreshOrderPayments(allocationOrderIDs(payment)...); err != nil {
+		return err
+	}
+	return s.UpdateCustomerStats(payment.CustomerID)
+}
+
+// GetPayment - Returns a payment by ID
+func (s *BleveStore) GetPayment(id string) (*Payment, error) {
+	var payment Payment
+	if err := s.readJSONFile(paymentsDir, id, &payment); err != nil {
+		return nil, fmt.Errorf("ödeme bulunamadı: %s", id)
+	}
+	payment.UnallocatedAmount = payment.BaseAmount - payment.AllocatedAmount()
+	return &payment, nil
+}
+
+// ListPayments - Payments, newest first; customerID and the dates are optional filters
+func (s *BleveStore) ListPayments(customerID string, start, end time.Time) ([]*Payment, error) {
+	ids, err := s.listJSONFileIDs(paymentsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	payments := []*Payment{}
+	for _, id := range ids {
+		var payment Payment
+		if err := s.readJSONFile(paymentsDir, id, &payment); err != nil {
+			continue
+		}
+		if customerID != "" && payment.CustomerID != customerID {
+			continue
+		}
+		if !start.IsZero() && payment.Date.Before(start) {
+			continue
+		}
+		if !end.IsZero() && payment.Date.After(end) {
+			continue
+		}
+		payment.UnallocatedAmount = payment.BaseAmount - payment.AllocatedAmount()
+		payments = append(payments, &payment)
+	}
+
+	sort.Slice(payments, func(i, j int) bool {
+		return payments[i].Date.After(payments[j].Date)
+	})
+
+	return payments, nil
+}
+
+// ledgerEntries - Müşterinin tüm cari hareketleri, eskiden yeniye (bakiye hesaplanmadan)
+func (s *BleveStore) ledgerEntries(customerID string) ([]*LedgerEntry, error) {
+	orders, err := s.GetCustomerOrders(customerID)
+	if err != nil {
+		return nil, err
+	}
+	payments, err := s.ListPayments(customerID, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*LedgerEntry
+	for _, order := range orders {
+		if !order.isSale() || order.BaseGrandTotal == 0 {
+			continue
+		}
+		description := order.Title
+		if description == "" {
+			description = "Sipariş"
+		}
+		entries = append(entries, &LedgerEntry{
+			Date:        order.saleDate(),
+			Type:        LedgerEntryOrder,
+			ReferenceID: order.ID,
+			Description: description,
+			Debit:       order.BaseGrandTotal,
+		})
+	}
+	for _, payment := range payments {
+		description := paymentMethodName(payment.Method)
+		if payment.Currency != GetBaseCurrency() {
+			description += fmt.Sprintf(" (%s %s)", payment.Amount, payment.Currency)
+		}
+		if payment.Note != "" {
+			description += " - " + payment.Note
+		}
+		entries = append(entries, &LedgerEntry{
+			Date:        payment.Date,
+			Type:        LedgerEntryPayment,
+			ReferenceID: payment.ID,
+			Description: description,
+			Credit:      payment.BaseAmount,
+		})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date.Before(entries[j].Date)
+	})
+
+	return entries, nil
+}
+
+// paymentMethodName - Ödeme yönteminin görünen adı
+func paymentMethodName(method string) string {
+	switch method {
+	case PaymentMethodCash:
+		return "Nakit"
+	case PaymentMethodCard:
+		return "Kart"
+	case PaymentMethodTransfer:
+		return "Havale/EFT"
+	}
+	return method
+}
+
+// GetCustomerLedger - Every order and payment of a customer with the running balance
+func (s *BleveStore) GetCustomerLedger(customerID string) (*CustomerStatement, error) {
+	return s.GetCustomerStatement(customerID, time.Time{}, time.Time{})
+}
+
+// GetCustomerStatement - Customer ledger for a period (zero dates = unbounded)
+// Entries before start are summed into the opening balance.
+func (s *BleveStore) GetCustomerStatement(customerID string, start, end time.Time) (*CustomerStatement, error) {
+	customer, err := s.GetCustomer(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.ledgerEntries(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &CustomerStatement{
+		CustomerID:   customer.ID,
+		CustomerName: customer.Name,
+		Phone:        customer.Phone,
+		Currency:     GetBaseCurrency(),
+		Entries:      []*LedgerEntry{},
+	}
+	if !start.IsZero() {
+		statement.StartDate = start.Format("2006-01-02")
+	}
+	if !end.IsZero() {
+		statement.EndDate = end.Format("2006-01-02")
+	}
+
+	balance := Money(0)
+	for _, entry := range entries {
+		if !end.IsZero() && entry.Date.After(end) {
+			break
+		}
+		balance += entry.Debit - entry.Credit
+		if !start.IsZero() && entry.Date.Before(start) {
+			statement.OpeningBalance = balance
+			continue
+		}
+		entry.Balance = balance
+		statement.Entries = append(statement.Entries, entry)
+		statement.TotalDebit += entry.Debit
+		statement.TotalCredit += entry.Credit
+	}
+	statement.ClosingBalance = balance
+
+	return statement, nil
+}
+
+// GetAgedReceivables - Open customer balances split by the age of the unpaid orders
+// Allocated payments close their orders, the rest closes the oldest orders first;
+// only customers with a balance are listed.
+func (s *BleveStore) GetAgedReceivables(at time.Time) (*AgedReceivablesReport, error) {
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	customers, err := s.ListCustomers()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AgedReceivablesReport{
+		Date:      at.Format("2006-01-02"),
+		Currency:  GetBaseCurrency(),
+		Customers: []*AgedReceivable{},
+	}
+
+	for _, customer := range customers {
+		entries, err := s.ledgerEntries(customer.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		payments, err := s.ListPayments(customer.ID, time.Time{}, at)
+		if err != nil {
+			return nil, err
+		}
+
+		// Önce siparişe dağıtılan tutarlar, kalan ödemeler en eski siparişten düşülür
+		type openOrder struct {
+			date   time.Time
+			amount Money
+		}
+		var open []*openOrder
+		allocated := make(map[string]Money)
+		paid := Money(0)    // Dağıtılmamış ödemeler
+		prepaid := Money(0) // Henüz satışa dönmemiş siparişlere dağıtılanlar
+		for _, payment := range payments {
+			paid += payment.UnallocatedAmount
+			for _, a := range payment.Allocations {
+				allocated[a.OrderID] += a.Amount
+				prepaid += a.Amount
+			}
+		}
+		for _, entry := range entries {
+			if entry.Date.After(at) {
+				break
+			}
+			if entry.Type != LedgerEntryOrder {
+				continue
+			}
+			applied := allocated[entry.ReferenceID].Min(entry.Debit)
+			prepaid -= applied
+			open = append(open, &openOrder{date: entry.Date, amount: entry.Debit - applied})
+		}
+
+		aged := &AgedReceivable{CustomerID: customer.ID, CustomerName: customer.Name, Phone: customer.Phone}
+		for _, o := range open {
+			applied := paid.Min(o.amount)
+			paid -= applied
+			if remaining := o.amount - applied; remaining > 0 {
+				aged.add(remaining, int(at.Sub(o.date).Hours()/24))
+			}
+		}
+		aged.Credit = paid + prepaid
+
+		if aged.Total == 0 && aged.Credit == 0 {
+			continue
+		}
+		report.Customers = append(report.Customers, aged)
+		report.Totals.Current += aged.Current
+		report.Totals.Days31To60 += aged.Days31To60
+		report.Totals.Days61To90 += aged.Days61To90
+		report.Totals.Over90 += aged.Over90
+		report.Totals.Total += aged.Total
+		report.Totals.Credit += aged.Credit
+	}
+
+	// En çok borcu olan önce
+	sort.Slice(report.Customers, func(i, j int) bool {
+		return report.Customers[i].Total > report.Customers[j].Total
+	})
+
+	return report, nil
+}
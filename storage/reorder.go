@@ -0,0 +1,393 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// ============================================
+// Yeniden Sipariş Planı
+// Ürün başına sipariş noktası (ReorderPoint) ve hedef seviye (ReorderTarget) ile
+// tercih edilen tedarikçi tutulur. Kullanılabilir stok + yoldaki (açık satın alma
+// siparişlerinde kalan) miktar sipariş noktasının altına düşen ürünler için hedef
+// seviyeye tamamlayan miktar önerilir ve öneriler tedarikçiye göre gruplanır.
+//
+// Sipariş noktası girilmemişse kritik stok ile tedarik süresi boyunca beklenen
+// tüketimin büyüğü kullanılır; hedef girilmemişse sipariş noktasına 30 günlük
+// tüketim (tüketim yoksa sipariş noktası kadar) eklenir. Tüketim son günlerdeki
+// "out" hareketlerinden hesaplanır.
+// ============================================
+
+// DefaultReorderUsageDays - Tüketim ortalaması için varsayılan geriye bakış süresi
+const DefaultReorderUsageDays = 90
+
+// reorderCoverDays - Hedef seviye girilmemişse karşılanacak tüketim günü
+const reorderCoverDays = 30
+
+// ReorderSettings - Per-product reorder parameters
+type ReorderSettings struct {
+	ReorderPoint        float64 `json:"reorder_point"`         // Order when available + on order falls below (0 = from critical stock)
+	ReorderTarget       float64 `json:"reorder_target"`        // Level to order up to (0 = automatic)
+	PreferredSupplierID string  `json:"preferred_supplier_id"` // Supplier to order from
+	LeadTimeDays        int     `json:"lead_time_days"`        // Days from order to delivery
+}
+
+// ReorderSuggestion - Suggested purchase of one product
+type ReorderSuggestion struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	OEMNumber   string `json:"oem_number"`
+	Brand       string `json:"brand"`
+	Unit        string `json:"unit"` // Base unit of the quantities below
+
+	OnHand    float64 `json:"on_hand"`
+	Reserved  float64 `json:"reserved"`
+	Available float64 `json:"available"`
+	OnOrder   float64 `json:"on_order"` // Still expected on open purchase orders
+
+	ReorderPoint  float64 `json:"reorder_point"`  // Effective reorder point
+	ReorderTarget float64 `json:"reorder_target"` // Effective target level
+	DailyUsage    float64 `json:"daily_usage"`    // Average "out" per day in the usage period
+
+	SuggestedQuantity float64 `json:"suggested_quantity"` // In the base unit
+	OrderUnit         string  `json:"order_unit"`         // Purchase unit
+	OrderQuantity     float64 `json:"order_quantity"`     // In OrderUnit, rounded up
+	UnitCost          Money   `json:"unit_cost"`          // Estimated cost per OrderUnit (last cost, base currency)
+	EstimatedCost     Money   `json:"estimated_cost"`
+}
+
+// SupplierReorderGroup - Suggestions for one supplier
+type SupplierReorderGroup struct {
+	SupplierID    string               `json:"supplier_id"` // Empty: no supplier known
+	SupplierName  string               `json:"supplier_name"`
+	Items         []*ReorderSuggestion `json:"items"`
+	EstimatedCost Money                `json:"estimated_cost"`
+}
+
+// ReorderPlan - Suggested purchase list grouped by supplier
+type ReorderPlan struct {
+	Date          string                  `json:"date"`
+	UsageDays     int                     `json:"usage_days"`
+	Currency      string                  `json:"currency"`
+	Groups        []*SupplierReorderGroup `json:"groups"`
+	ItemCount     int                     `json:"item_count"`
+	EstimatedCost Money                   `json:"estimated_cost"`
+}
+
+// SetProductReorder - Sets a product's reorder point, target level, preferred supplier and lead time
+func (s *BleveStore) SetProductReorder(productID string, settings ReorderSettings) error {
+	if settings.ReorderPoint < 0 || settings.ReorderTarget < 0 || settings.LeadTimeDays < 0 {
+		return fmt.Errorf("sipariş noktası, hedef seviye ve tedarik süresi negatif olamaz")
+	}
+	if settings.ReorderTarget > 0 && settings.ReorderTarget < settings.ReorderPoint {
+		return fmt.Errorf("hedef seviye sipariş noktasından küçük olamaz")
+	}
+	if settings.PreferredSupplierID != "" {
+		supplier, err := s.GetCustomer(settings.PreferredSupplierID)
+		if err != nil {
+			return fmt.Errorf("tedarikçi bulunamadı: %w", err)
+		}
+		if !supplier.IsSupplier {
+			return fmt.Errorf("%s tedarikçi olarak işaretli değil", supplier.Name)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, err := s.GetProduct(productID)
+	if err != nil {
+		return err
+	}
+
+	product.ReorderPoint = settings.ReorderPoint
+	product.ReorderTarget = settings.ReorderTarget
+	product.PreferredSupplierID = settings.PreferredSupplierID
+	product.LeadTimeDays = settings.LeadTimeDays
+	product.UpdatedAt = time.Now()
+
+	return s.SaveProduct(product)
+}
+
+// dailyUsage - Ürün başına son usageDays gündeki ortalama günlük çıkış (güncel temel birimde)
+// İptal edilen çıkışlar ve karşı hareketleri sayılmaz.
+func (s *BleveStore) dailyUsage(usageDays int) (map[string]float64, error) {
+	movements, err := s.ListStockMovements()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.toCurrentUnits(movements); err != nil {
+		return nil, err
+	}
+
+	since := time.Now().AddDate(0, 0, -usageDays)
+	usage := make(map[string]float64)
+	for _, m := range movements {
+		if m.MovementType != MovementTypeOut || m.Date.Before(since) {
+			continue
+		}
+		if m.IsReversed() || m.ReversalOf != "" {
+			continue
+		}
+		usage[m.ProductID] += m.Amount
+	}
+
+	for id, total := range usage {
+		usage[id] = total / float64(usageDays)
+	}
+	return usage, nil
+}
+
+// openPurchaseQuantities - Açık satın alma siparişlerinde beklenen miktarlar (temel birimde)
+// ve her ürün için en son sipariş verilen tedarikçi
+func (s *BleveStore) openPurchaseQuantities(products map[string]*Product) (map[string]float64, map[string]string, error) {
+	orders, err := s.ListPurchaseOrders("", "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	onOrder := make(map[string]float64)
+	lastSupplier := make(map[string]string)
+	// ListPurchaseOrders yeniden eskiye sıralar
+	for _, po := range orders {
+		for i := range po.Items {
+			item := &po.Items[i]
+			if _, ok := lastSupplier[item.ProductID]; !ok {
+				lastSupplier[item.ProductID] = po.SupplierID
+			}
+			if !po.IsOpen() {
+				continue
+			}
+			factor := 1.0
+			if p := products[item.ProductID]; p != nil {
+				if f, ok := p.UnitFactor(item.Unit); ok {
+					factor = f
+				}
+			}
+			onOrder[item.ProductID] += item.Remaining() * factor
+		}
+	}
+
+	return onOrder, lastSupplier, nil
+}
+
+// roundUpQuantity - Miktarı birimin ondalık kuralına göre yukarı yuvarlar
+func roundUpQuantity(quantity float64, unit string) float64 {
+	scale := math.Pow10(QuantityDecimals(unit))
+	return math.Ceil(math.Round(quantity*scale*quantityScale)/quantityScale) / scale
+}
+
+// GetReorderPlan - Suggested purchases grouped by supplier
+// usageDays is the look-back period for average consumption (0 = DefaultReorderUsageDays).
+func (s *BleveStore) GetReorderPlan(usageDays int) (*ReorderPlan, error) {
+	if usageDays <= 0 {
+		usageDays = DefaultReorderUsageDays
+	}
+
+	products, err := s.productsByID()
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.dailyUsage(usageDays)
+	if err != nil {
+		return nil, err
+	}
+	onOrder, lastSupplier, err := s.openPurchaseQuantities(products)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &ReorderPlan{
+		Date:      time.Now().Format("2006-01-02"),
+		UsageDays: usageDays,
+		Currency:  GetBaseCurrency(),
+		Groups:    []*SupplierReorderGroup{},
+	}
+	groups := make(map[string]*SupplierReorderGroup)
+
+	for _, p := range products {
+		daily := usage[p.ID]
+
+		point := p.ReorderPoint
+		if point == 0 {
+			critical := p.CriticalStock
+			if critical == 0 {
+				critical = 3 // Default
+			}
+			point = math.Max(float64(critical), daily*float64(p.LeadTimeDays))
+		}
+		target := p.ReorderTarget
+		if target == 0 {
+			target = point + daily*reorderCoverDays
+			if daily == 0 {
+				target = point * 2
+			}
+		}
+
+		position := p.Available() + onOrder[p.ID]
+		if position >= point {
+			continue
+		}
+
+		suggested := math.Round((target-position)*quantityScale) / quantityScale
+		if suggested <= 0 {
+			continue
+		}
+
+		orderUnit := p.PurchaseUnit
+		factor, ok := p.UnitFactor(orderUnit)
+		if orderUnit == "" || !ok {
+			orderUnit, factor = p.Unit, 1
+		}
+		orderQuantity := roundUpQuantity(suggested/factor, orderUnit)
+
+		unitCost := p.LastCost
+		if unitCost == 0 {
+			unitCost = p.AverageCost
+		}
+		unitCost = unitCost.MulRate(factor)
+
+		suggestion := &ReorderSuggestion{
+			ProductID:         p.ID,
+			ProductName:       p.Name,
+			OEMNumber:         p.OEMNumber,
+			Brand:             p.Brand,
+			Unit:              p.Unit,
+			OnHand:            p.StockQuantity,
+			Reserved:          p.ReservedQuantity,
+			Available:         p.Available(),
+			OnOrder:           math.Round(onOrder[p.ID]*quantityScale) / quantityScale,
+			ReorderPoint:      math.Round(point*quantityScale) / quantityScale,
+			ReorderTarget:     math.Round(target*quantityScale) / quantityScale,
+			DailyUsage:        math.Round(daily*quantityScale) / quantityScale,
+			SuggestedQuantity: suggested,
+			OrderUnit:         orderUnit,
+			OrderQuantity:     orderQuantity,
+			UnitCost:          unitCost,
+			EstimatedCost:     unitCost.MulQuantity(orderQuantity),
+		}
+
+		supplierID := p.PreferredSupplierID
+		if supplierID == "" {
+			supplierID = lastSupplier[p.ID]
+		}
+		group, ok := groups[supplierID]
+		if !ok {
+			group = &SupplierReorderGroup{SupplierID: supplierID, SupplierName: "Tedarikçi belirtilmemiş", Items: []*ReorderSuggestion{}}
+			if supplierID != "" {
+				if supplier, err := s.GetCustomer(supplierID); err == nil {
+					group.SupplierName = supplier.Name
+				}
+			}
+			groups[supplierID] = group
+			plan.Groups = append(plan.Groups, group)
+		}
+		group.Items = append(group.Items, suggestion)
+		group.EstimatedCost += suggestion.EstimatedCost
+		plan.ItemCount++
+		plan.EstimatedCost += suggestion.EstimatedCost
+	}
+
+	// Tedarikçisizler en sonda, diğerleri ada göre; kalemler ada göre
+	sort.Slice(plan.Groups, func(i, j int) bool {
+		a, b := plan.Groups[i], plan.Groups[j]
+		if (a.SupplierID == "") != (b.SupplierID == "") {
+			return b.SupplierID == ""
+		}
+		return a.SupplierName < b.SupplierName
+	})
+	for _, group := range plan.Groups {
+		sort.Slice(group.Items, func(i, j int) bool {
+			return group.Items[i].ProductName < group.Items[j].ProductName
+		})
+	}
+
+	return plan, nil
+}
+
+// CreateReorderPurchaseOrders - Turns the reorder plan into purchase orders, one per supplier
+// supplierIDs limits which groups are ordered (empty = every group with a supplier).
+// Prices are the estimated last costs in the base currency.
+func (s *BleveStore) CreateReorderPurchaseOrders(supplierIDs []string, usageDays int) ([]*PurchaseOrder, error) {
+	plan, err := s.GetReorderPlan(usageDays)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool)
+	for _, id := range supplierIDs {
+		selected[id] = true
+	}
+
+	created := []*PurchaseOrder{}
+	for _, group := range plan.Groups {
+		if group.SupplierID == "" || (len(selected) > 0 && !selected[group.SupplierID]) {
+			continue
+		}
+
+		po := &PurchaseOrder{
+			SupplierID: group.SupplierID,
+			Note:       "Yeniden sipariş önerisinden oluşturuldu",
+		}
+		for _, item := range group.Items {
+			po.Items = append(po.Items, PurchaseOrderItem{
+				ProductID: item.ProductID,
+				Unit:      item.OrderUnit,
+				Quantity:  item.OrderQuantity,
+				UnitCost:  item.UnitCost,
+			})
+		}
+
+		po, err := s.CreatePurchaseOrder(po)
+		if err != nil {
+			return created, fmt.Errorf("%s: %w", group.SupplierName, err)
+		}
+		created = append(created, po)
+	}
+
+	return created, nil
+}
+
+// ExportReorderPlanCSV - The reorder plan as CSV (";" separated, for spreadsheets)
+func (s *BleveStore) ExportReorderPlanCSV(usageDays int) (string, error) {
+	plan, err := s.GetReorderPlan(usageDays)
+	if err != nil {
+		return "", err
+	}
+
+	quantity := func(q float64) string {
+		return strconv.FormatFloat(q, 'f', -1, 64)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = ';'
+	w.Write([]string{
+		"Tedarikçi", "Ürün", "OEM", "Marka", "Eldeki", "Rezerve", "Yoldaki",
+		"Sipariş Noktası", "Hedef", "Günlük Tüketim", "Önerilen", "Birim",
+		"Sipariş Miktarı", "Sipariş Birimi", "Birim Maliyet", "Tahmini Tutar",
+	})
+	for _, group := range plan.Groups {
+		for _, item := range group.Items {
+			w.Write([]string{
+				group.SupplierName, item.ProductName, item.OEMNumber, item.Brand,
+				quantity(item.OnHand), quantity(item.Reserved), quantity(item.OnOrder),
+				quantity(item.ReorderPoint), quantity(item.ReorderTarget), quantity(item.DailyUsage),
+				quantity(item.SuggestedQuantity), item.Unit,
+				quantity(item.OrderQuantity), item.OrderUnit,
+				item.UnitCost.String(), item.EstimatedCost.String(),
+			})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
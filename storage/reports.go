@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SalesReport - Sales report result
+// Amounts are in the base currency, each order converted with the rate of its order date
+type SalesReport struct {
+	Period              string         `json:"period"`   // "daily" or "monthly"
+	Date                string         `json:"date"`     // "2024-01-15" or "2024-01"
+	Currency            string         `json:"currency"` // Base currency
+	OrderCount          int            `json:"order_count"`
+	ListTotal           Money          `json:"list_total"`            // Before any discount
+	ItemDiscountTotal   Money          `json:"item_discount_total"`   // Line discounts
+	OrderDiscountTotal  Money          `json:"order_discount_total"`  // Order-level discounts
+	DiscountTotal       Money          `json:"discount_total"`        // All discounts
+	Subtotal            Money          `json:"subtotal"`              // Net of tax
+	PartsTotal          Money          `json:"parts_total"`           // Parts share of Subtotal
+	LaborTotal          Money          `json:"labor_total"`           // Labor and service share of Subtotal
+	TaxTotal            Money          `json:"tax_total"`             // KDV
+	GrandTotal          Money          `json:"grand_total"`           // Including tax
+	TaxBreakdown        []TaxBreakdown `json:"tax_breakdown"`         // KDV by rate
+	DiscountedOrderRate float64        `json:"discounted_order_rate"` // Share of orders with any discount (%)
+	Orders              []*Order       `json:"orders"`
+	Warnings            []string       `json:"warnings"` // e.g. orders skipped for a missing exchange rate
+}
+
+// reportPeriodRange - Returns [start, end) for a daily or monthly report period
+func reportPeriodRange(period string, date time.Time) (time.Time, time.Time) {
+	if period == "daily" {
+		start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+		return start, start.Add(24 * time.Hour)
+	}
+
+	// Monthly
+	start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
+	return start, start.AddDate(0, 1, 0)
+}
+
+// GetSalesReport - Generate daily or monthly sales report with discount and tax totals
+func (s *BleveStore) GetSalesReport(period string, date time.Time) (*SalesReport, error) {
+	start, end := reportPeriodRange(period, date)
+
+	allOrders, err := s.ListOrders()
+	if err != nil {
+		return nil, err
+	}
+
+	rates, err := s.loadExchangeRateTable()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SalesReport{
+		Period:   period,
+		Currency: GetBaseCurrency(),
+		Orders:   []*Order{},
+		Warnings: []string{},
+	}
+
+	if period == "daily" {
+		report.Date = date.Format("2006-01-02")
+	} else {
+		report.Date = date.Format("2006-01")
+	}
+
+	byRate := make(map[float64]*TaxBreakdown)
+	discounted := 0
+
+	for _, order := range allOrders {
+		if !order.isSale() {
+			continue
+		}
+		if date := order.saleDate(); date.Before(start) || !date.Before(end) {
+			continue
+		}
+
+		rate, err := rates.orderRate(order)
+		if err != nil {
+			label := order.Title
+			if label == "" {
+				label = order.ID
+			}
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s: %v", label, err))
+			continue
+		}
+
+		report.Orders = append(report.Orders, order)
+		report.OrderCount++
+		report.ListTotal += order.ListTotal.MulRate(rate)
+		report.ItemDiscountTotal += order.ItemDiscountTotal.MulRate(rate)
+		report.OrderDiscountTotal += order.OrderDiscountAmount.MulRate(rate)
+		report.DiscountTotal += order.DiscountTotal.MulRate(rate)
+		report.Subtotal += order.Subtotal.MulRate(rate)
+		report.PartsTotal += order.PartsTotal.MulRate(rate)
+		report.LaborTotal += order.LaborTotal.MulRate(rate)
+		report.TaxTotal += order.TaxTotal.MulRate(rate)
+		report.GrandTotal += order.GrandTotal.MulRate(rate)
+
+		if order.DiscountTotal > 0 {
+			discounted++
+		}
+
+		for _, b := range order.TaxBreakdown {
+			if _, ok := byRate[b.Rate]; !ok {
+				byRate[b.Rate] = &TaxBreakdown{Rate: b.Rate}
+			}
+			byRate[b.Rate].Base += b.Base.MulRate(rate)
+			byRate[b.Rate].Tax += b.Tax.MulRate(rate)
+		}
+	}
+
+	if report.OrderCount > 0 {
+		report.DiscountedOrderRate = float64(discounted) * 100 / float64(report.OrderCount)
+	}
+
+	report.TaxBreakdown = make([]TaxBreakdown, 0, len(byRate))
+	for _, b := range byRate {
+		report.TaxBreakdown = append(report.TaxBreakdown, *b)
+	}
+	sort.Slice(report.TaxBreakdown, func(i, j int) bool {
+		return report.TaxBreakdown[i].Rate < report.TaxBreakdown[j].Rate
+	})
+
+	// Newest first
+	sort.Slice(report.Orders, func(i, j int) bool {
+		return report.Orders[i].CreatedAt.After(report.Orders[j].CreatedAt)
+	})
+
+	return report, nil
+}
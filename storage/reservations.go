@@ -0,0 +1,333 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ============================================
+// Stok Rezervasyonu
+// Onaylanan (confirmed) siparişler kalemlerindeki katalog ürünlerini müşteri için
+// ayırır. Ayrılan miktar ürünün ReservedQuantity alanında tutulur ve sipariş
+// kaydedildiğinde, durumu değiştiğinde veya silindiğinde onaylı siparişlerden
+// yeniden hesaplanır. Kullanılabilir stok = eldeki stok - rezerve.
+//
+// Rezervasyonlar yer bazında değildir; bir yerden yapılabilecek çıkış o yerdeki
+// stok ile toplam kullanılabilir stoğun küçüğüdür.
+// ============================================
+
+// Order statuses
+const (
+	OrderStatusDraft     = "draft"     // Taslak / teklif (stok ayırmaz)
+	OrderStatusConfirmed = "confirmed" // Onaylandı, teslim bekliyor (stok ayırır)
+	OrderStatusDelivered = "delivered" // Teslim edildi, stoktan düşüldü
+)
+
+// ProductReservation - One confirmed order's reservation of a product
+type ProductReservation struct {
+	OrderID      string    `json:"order_id"`
+	OrderTitle   string    `json:"order_title"`
+	CustomerName string    `json:"customer_name"`
+	Quantity     float64   `json:"quantity"` // In the product's base unit
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Available - Kullanılabilir stok (eldeki - rezerve); eksiye düşebilir
+func (p *Product) Available() float64 {
+	return math.Round((p.StockQuantity-p.ReservedQuantity)*quantityScale) / quantityScale
+}
+
+// AvailableAt - Yerden çıkılabilecek miktar (yerdeki stok ile kullanılabilir stoğun küçüğü)
+func (p *Product) AvailableAt(locationID string) float64 {
+	return math.Min(p.QuantityAt(locationID), p.Available())
+}
+
+// reservesStock - Sipariş stok ayırıyor mu
+func (o *Order) reservesStock() bool {
+	return o.Status == OrderStatusConfirmed
+}
+
+// isSale - Sipariş satış olarak sayılır mı (taslak ve teslim bekleyenler hariç)
+func (o *Order) isSale() bool {
+	return o.Status != OrderStatusDraft && o.Status != OrderStatusConfirmed
+}
+
+// saleDate - Satış tarihi (teslim edildiyse teslim, değilse kayıt tarihi)
+func (o *Order) saleDate() time.Time {
+	if o.DeliveredAt != nil {
+		return *o.DeliveredAt
+	}
+	return o.CreatedAt
+}
+
+// orderReservations - Siparişin ürün bazında ayırdığı miktarlar (ürünün güncel temel biriminde)
+func (o *Order) orderReservations(products map[string]*Product) map[string]float64 {
+	quantities := make(map[string]float64)
+	for i := range o.Items {
+		item := &o.Items[i]
+		if item.ProductID == "" {
+			continue
+		}
+		quantities[item.ProductID] += item.baseQuantityFor(products[item.ProductID])
+	}
+	return quantities
+}
+
+// productsByID - Ürünleri ID'ye göre haritalar
+func (s *BleveStore) productsByID() (map[string]*Product, error) {
+	products, err := s.ListProducts()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+	return byID, nil
+}
+
+// refreshReservations - Onaylı siparişlerden ürünlerin rezerve miktarlarını yeniden hesaplar
+// Yalnızca değişen ürünler kaydedilir.
+func (s *BleveStore) refreshReservations() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.recalculateReservations("")
+}
+
+// recalculateReservations - refreshReservations gövdesi; skipOrderID siparişinin ayırdığı
+// miktar sayılmaz (teslim sırasında). Çağıran s.mu'yu tutmalıdır
+func (s *BleveStore) recalculateReservations(skipOrderID string) error {
+	orders, err := s.ListOrders()
+	if err != nil {
+		return err
+	}
+
+	products, err := s.productsByID()
+	if err != nil {
+		return err
+	}
+
+	reserved := make(map[string]float64)
+	for _, order := range orders {
+		if !order.reservesStock() || order.ID == skipOrderID {
+			continue
+		}
+		for productID, quantity := range order.orderReservations(products) {
+			reserved[productID] += quantity
+		}
+	}
+
+	for _, p := range products {
+		quantity := math.Round(reserved[p.ID]*quantityScale) / quantityScale
+		if quantity == p.ReservedQuantity {
+			continue
+		}
+		p.ReservedQuantity = quantity
+		if err := s.SaveProduct(p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetProductReservations - Confirmed orders holding stock of a product
+func (s *BleveStore) GetProductReservations(productID string) ([]*ProductReservation, error) {
+	orders, err := s.ListOrders()
+	if err != nil {
+		return nil, err
+	}
+	products, err := s.productsByID()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*ProductReservation{}
+	for _, order := range orders {
+		if !order.reservesStock() {
+			continue
+		}
+		quantity := order.orderReservations(products)[productID]
+		if quantity == 0 {
+			continue
+		}
+		result = append(result, &ProductReservation{
+			OrderID:      order.ID,
+			OrderTitle:   order.Title,
+			CustomerName: order.CustomerName,
+			Quantity:     quantity,
+			CreatedAt:    order.CreatedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// SetOrderStatus - Moves an order between draft and confirmed
+// Confirming reserves the order's catalog products, going back to draft releases them.
+// Delivered orders cannot be changed; use DeliverOrder to deliver. Orders saved before
+// statuses existed (empty status) count as completed sales and stay as they are.
+func (s *BleveStore) SetOrderStatus(orderID, status string) error {
+	if status != OrderStatusDraft && status != OrderStatusConfirmed {
+		return fmt.Errorf("geçersiz sipariş durumu: %q", status)
+	}
+
+	order, err := s.GetOrder(orderID)
+	if err != nil {
+		return err
+	}
+	if order.Status == OrderStatusDelivered {
+		return fmt.Errorf("teslim edilmiş siparişin durumu değiştirilemez")
+	}
+	if order.Status == "" {
+		return fmt.Errorf("durumsuz (eski) siparişin durumu değiştirilemez")
+	}
+	if order.Status == status {
+		return nil
+	}
+
+	// Taslağa dönen siparişin ödeme dağıtımları serbest kalır
+	order.Status = status
+	released, err := s.applyOrderPayments(order)
+	if err != nil {
+		return err
+	}
+	if err := s.writeOrder(order); err != nil {
+		return err
+	}
+	if err := s.savePayments(released); err != nil {
+		return err
+	}
+	s.UpdateCustomerStats(order.CustomerID)
+
+	return s.refreshReservations()
+}
+
+// DeliverOrder - Delivers an order: releases its reservation and takes its catalog products out of stock
+// Availability and serial numbers of every item are checked first (lots are taken FEFO
+// without a lot number and cannot fall short); if a stock-out still fails, the ones
+// already posted are reversed and the order keeps its status. The order is marked
+// delivered only after every item is out. Orders without a status (saved before
+// statuses existed) count as completed sales and cannot be delivered.
+func (s *BleveStore) DeliverOrder(orderID, locationID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, err := s.GetOrder(orderID)
+	if err != nil {
+		return err
+	}
+	if order.Status == OrderStatusDelivered {
+		return fmt.Errorf("sipariş zaten teslim edilmiş")
+	}
+	if order.Status == "" {
+		return fmt.Errorf("durumsuz (eski) sipariş teslim edilemez")
+	}
+
+	locationID, err = s.resolveLocation(locationID)
+	if err != nil {
+		return err
+	}
+
+	// Kendi rezervasyonu düşüldükten sonra stok yetiyor mu
+	products, err := s.productsByID()
+	if err != nil {
+		return err
+	}
+	for productID, quantity := range order.orderReservations(products) {
+		product := products[productID]
+		if product == nil {
+			return fmt.Errorf("ürün bulunamadı: %s", productID)
+		}
+		if order.reservesStock() {
+			product.ReservedQuantity -= quantity
+		}
+		if available := product.AvailableAt(locationID); available < quantity-stockTolerance {
+			return fmt.Errorf("%s: yetersiz kullanılabilir stok (mevcut %.2f, gereken %.2f)", product.Name, available, quantity)
+		}
+	}
+
+	// Seri numaraları
+	for i := range order.Items {
+		item := &order.Items[i]
+		product := products[item.ProductID]
+		if item.ProductID == "" || product == nil {
+			continue
+		}
+		amount, _, err := product.entryQuantity(item.Quantity, item.Unit)
+		if err != nil {
+			return fmt.Errorf("%s: %w", item.ProductName, err)
+		}
+		if product.SerialTracking {
+			numbers, err := normalizeSerialList(item.SerialNumbers)
+			if err == nil {
+				err = checkSerialCount(product, numbers, amount)
+			}
+			if err == nil {
+				_, err = s.checkSerialsInStock(product, numbers, locationID, order.ID)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", item.ProductName, err)
+			}
+		}
+	}
+
+	// Rezervasyonu bırak, kalemleri düş; hata olursa yazılan çıkışları geri al
+	if err := s.recalculateReservations(order.ID); err != nil {
+		return err
+	}
+	note := "Sipariş teslimi"
+	if order.Title != "" {
+		note += ": " + order.Title
+	}
+	var posted []*StockMovement
+	rollback := func(cause error) error {
+		for i := len(posted) - 1; i >= 0; i-- {
+			if _, err := s.reverseStockMovement(posted[i].ID, "Teslim geri alındı"); err != nil {
+				cause = fmt.Errorf("%w; geri alınamadı: %v", cause, err)
+			}
+		}
+		s.recalculateReservations("")
+		return cause
+	}
+
+	for _, item := range order.Items {
+		if item.ProductID == "" {
+			continue
+		}
+		movement, err := s.stockOutEntry(BulkStockInfo{
+			ProductID:     item.ProductID,
+			Amount:        item.Quantity,
+			Unit:          item.Unit,
+			Note:          note,
+			LocationID:    locationID,
+			SerialNumbers: item.SerialNumbers,
+			OrderID:       order.ID,
+			VehicleID:     order.VehicleID,
+		})
+		if movement != nil {
+			posted = append(posted, movement)
+		}
+		if err != nil {
+			return rollback(fmt.Errorf("stok çıkışı yapılamadı: %s: %w", item.ProductName, err))
+		}
+	}
+
+	now := time.Now()
+	order.Status = OrderStatusDelivered
+	order.DeliveredAt = &now
+	if err := s.writeOrder(order); err != nil {
+		return rollback(err)
+	}
+	if err := s.recalculateReservations(""); err != nil {
+		return err
+	}
+	if err := s.markOrderSerialsSold(order); err != nil {
+		return err
+	}
+	s.UpdateCustomerStats(order.CustomerID)
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package storage
+
+import "testing"
+
+// newReservationFixture - 5 adet stoklu ürün ve kayıtlı müşteri
+func newReservationFixture(t *testing.T) (*BleveStore, *Product, *Customer) {
+	t.Helper()
+	s := newTestStore(t)
+
+	product, err := s.CreateProductFull("Fren balatası", "FB-100", "Bosch", "Fren", UnitPiece, 0, 1)
+	if err != nil {
+		t.Fatalf("CreateProductFull: %v", err)
+	}
+	if err := s.StockInEntry(BulkStockInfo{ProductID: product.ID, Amount: 5, UnitCost: 10000}); err != nil {
+		t.Fatalf("StockInEntry: %v", err)
+	}
+
+	customer := NewCustomer("Mehmet Demir")
+	if err := s.SaveCustomer(customer); err != nil {
+		t.Fatalf("SaveCustomer: %v", err)
+	}
+	return s, product, customer
+}
+
+// saveTestOrder - Ürünün quantity adetlik kalemiyle verilen durumda sipariş kaydeder
+func saveTestOrder(t *testing.T, s *BleveStore, product *Product, customer *Customer, quantity float64, status string) *Order {
+	t.Helper()
+	order := NewOrderWithCustomer(customer.ID, customer.Name)
+	order.Status = status
+	order.Items = append(order.Items, NewOrderItem(product.Name, product.OEMNumber, quantity, 25000, "original"))
+	if err := s.SaveOrder(order); err != nil {
+		t.Fatalf("SaveOrder: %v", err)
+	}
+	return order
+}
+
+// checkStock - Ürünün eldeki ve rezerve miktarını doğrular
+func checkStock(t *testing.T, s *BleveStore, productID string, wantStock, wantReserved float64) {
+	t.Helper()
+	product, err := s.GetProduct(productID)
+	if err != nil {
+		t.Fatalf("GetProduct: %v", err)
+	}
+	if product.StockQuantity != wantStock || product.ReservedQuantity != wantReserved {
+		t.Errorf("stock %v reserved %v, want %v and %v", product.StockQuantity, product.ReservedQuantity, wantStock, wantReserved)
+	}
+}
+
+func TestOrderStatusReservations(t *testing.T) {
+	s, product, customer := newReservationFixture(t)
+
+	draft := saveTestOrder(t, s, product, customer, 2, "")
+	if draft.Status != OrderStatusDraft {
+		t.Fatalf("new order status = %q, want draft", draft.Status)
+	}
+	checkStock(t, s, product.ID, 5, 0)
+
+	saveTestOrder(t, s, product, customer, 1, OrderStatusConfirmed)
+	checkStock(t, s, product.ID, 5, 1)
+
+	steps := []struct {
+		status       string
+		wantReserved float64
+	}{
+		{OrderStatusConfirmed, 3},
+		{OrderStatusDraft, 1},
+		{OrderStatusConfirmed, 3},
+	}
+	for _, step := range steps {
+		if err := s.SetOrderStatus(draft.ID, step.status); err != nil {
+			t.Fatalf("SetOrderStatus(%s): %v", step.status, err)
+		}
+		checkStock(t, s, product.ID, 5, step.wantReserved)
+	}
+
+	if err := s.SetOrderStatus(draft.ID, OrderStatusDelivered); err == nil {
+		t.Error("SetOrderStatus(delivered) succeeded, want error")
+	}
+}
+
+func TestDeliverOrder(t *testing.T) {
+	s, product, customer := newReservationFixture(t)
+	warehouse, err := s.CreateLocation("Arka depo", "")
+	if err != nil {
+		t.Fatalf("CreateLocation: %v", err)
+	}
+
+	other := saveTestOrder(t, s, product, customer, 3, OrderStatusConfirmed)
+	order := saveTestOrder(t, s, product, customer, 2, OrderStatusConfirmed)
+	checkStock(t, s, product.ID, 5, 5)
+
+	// Stok başka yerde: teslim reddedilir, hiçbir şey değişmez
+	if err := s.DeliverOrder(order.ID, warehouse.ID); err == nil {
+		t.Fatal("DeliverOrder from an empty location succeeded, want error")
+	}
+	checkStock(t, s, product.ID, 5, 5)
+	if got, _ := s.GetOrder(order.ID); got.Status != OrderStatusConfirmed {
+		t.Errorf("status after failed delivery = %q, want confirmed", got.Status)
+	}
+
+	// Teslim kendi rezervasyonunu kullanır, diğer siparişinkine dokunmaz
+	if err := s.DeliverOrder(order.ID, ""); err != nil {
+		t.Fatalf("DeliverOrder: %v", err)
+	}
+	checkStock(t, s, product.ID, 3, 3)
+	delivered, err := s.GetOrder(order.ID)
+	if err != nil {
+		t.Fatalf("GetOrder: %v", err)
+	}
+	if delivered.Status != OrderStatusDelivered || delivered.DeliveredAt == nil {
+		t.Errorf("status %q delivered at %v, want delivered with a date", delivered.Status, delivered.DeliveredAt)
+	}
+	if err := s.DeliverOrder(order.ID, ""); err == nil {
+		t.Error("second DeliverOrder succeeded, want error")
+	}
+
+	// Teslim çıkışı tek başına geri alınamaz
+	movements, err := s.ListStockMovements()
+	if err != nil {
+		t.Fatalf("ListStockMovements: %v", err)
+	}
+	var out *StockMovement
+	for _, m := range movements {
+		if m.OrderID == order.ID && m.MovementType == MovementTypeOut {
+			out = m
+		}
+	}
+	if out == nil || out.Amount != 2 {
+		t.Fatalf("delivery stock-out = %+v, want 2 out for the order", out)
+	}
+	if _, err := s.ReverseStockMovement(out.ID, ""); err == nil {
+		t.Error("ReverseStockMovement on a delivery stock-out succeeded, want error")
+	}
+
+	// Diğer sipariş ancak kalan stokla teslim edilebilir
+	if err := s.DeliverOrder(other.ID, ""); err != nil {
+		t.Fatalf("DeliverOrder(other): %v", err)
+	}
+	checkStock(t, s, product.ID, 0, 0)
+}
+
+func TestDeliverOrderRespectsOtherReservations(t *testing.T) {
+	s, product, customer := newReservationFixture(t)
+
+	saveTestOrder(t, s, product, customer, 4, OrderStatusConfirmed)
+	draft := saveTestOrder(t, s, product, customer, 2, OrderStatusDraft)
+
+	// 5 eldeki - 4 başka siparişe ayrılmış = 1 kullanılabilir
+	if err := s.DeliverOrder(draft.ID, ""); err == nil {
+		t.Fatal("DeliverOrder beyond available stock succeeded, want error")
+	}
+	checkStock(t, s, product.ID, 5, 4)
+}
+
+func TestDeliverLegacyOrder(t *testing.T) {
+	s, product, customer := newReservationFixture(t)
+
+	order := saveTestOrder(t, s, product, customer, 1, OrderStatusDraft)
+	order.Status = ""
+	if err := s.writeOrder(order); err != nil {
+		t.Fatalf("writeOrder: %v", err)
+	}
+
+	if err := s.DeliverOrder(order.ID, ""); err == nil {
+		t.Error("DeliverOrder on a legacy order succeeded, want error")
+	}
+	if err := s.SetOrderStatus(order.ID, OrderStatusConfirmed); err == nil {
+		t.Error("SetOrderStatus on a legacy order succeeded, want error")
+	}
+	checkStock(t, s, product.ID, 5, 0)
+}
@@ -0,0 +1,457 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ============================================
+// Seri Numarası Takibi
+// SerialTracking açık ürünlerde (akü, turbo, beyin vb.) her birim seri numarasıyla
+// izlenir: giriş seri numaralarını kaydeder, çıkış ve sipariş kalemi belirli seri
+// numaralarını seçer. Her seri numarasının geçmişi garanti takibi için saklanır.
+// ============================================
+
+// Serial number statuses (physical)
+const (
+	SerialInStock  = "in_stock" // Depoda
+	SerialOut      = "out"      // Stoktan çıktı
+	SerialReturned = "returned" // Müşteriden iade (stoğa alınmadı, örn. garanti)
+)
+
+// Serial history event types
+const (
+	SerialEventReceived   = "received"   // Stok girişi
+	SerialEventIssued     = "issued"     // Stok çıkışı
+	SerialEventAssigned   = "assigned"   // Siparişe ayrıldı (teslim bekliyor)
+	SerialEventSold       = "sold"       // Sipariş teslim edildi, müşteriye satıldı
+	SerialEventUnassigned = "unassigned" // Siparişten çıkarıldı
+	SerialEventReturned   = "returned"   // Müşteriden iade
+	SerialEventTransfer   = "transfer"   // Stok yeri değişti
+	SerialEventReversed   = "reversed"   // Hareket iptal edildi
+)
+
+const serialsDir = "serials"
+
+// SerialEvent - One entry in a serial number's history
+type SerialEvent struct {
+	Type         string    `json:"type"`
+	Date         time.Time `json:"date"`
+	MovementID   string    `json:"movement_id,omitempty"`
+	OrderID      string    `json:"order_id,omitempty"`
+	OrderTitle   string    `json:"order_title,omitempty"`
+	CustomerID   string    `json:"customer_id,omitempty"`
+	CustomerName string    `json:"customer_name,omitempty"`
+	LocationID   string    `json:"location_id,omitempty"`
+	Note         string    `json:"note,omitempty"`
+}
+
+// SerialNumber - A single tracked unit
+type SerialNumber struct {
+	ID           string         `json:"id"`
+	ProductID    string         `json:"product_id"`
+	ProductName  string         `json:"product_name"`
+	Serial       string         `json:"serial"`
+	Status       string         `json:"status"` // "in_stock", "out" or "returned"
+	LocationID   string         `json:"location_id,omitempty"`
+	OrderID      string         `json:"order_id,omitempty"`    // Order the unit is assigned to or was sold on
+	CustomerID   string         `json:"customer_id,omitempty"` // Set once the order counts as a sale
+	CustomerName string         `json:"customer_name,omitempty"`
+	History      []*SerialEvent `json:"history"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// normalizeSerial - Seri numarasını karşılaştırma için büyük harfe çevirir
+func normalizeSerial(serial string) string {
+	return strings.ToUpper(strings.TrimSpace(serial))
+}
+
+// serialID - Ürün ve seri numarasından dosya adına uygun ID
+func serialID(productID, serial string) string {
+	var b strings.Builder
+	for _, r := range normalizeSerial(serial) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' {
+			b.WriteRune(r)
+		} else {
+			fmt.Fprintf(&b, "_%x", r)
+		}
+	}
+	return productID + "_" + b.String()
+}
+
+// normalizeSerialList - Boşları atar, büyük harfe çevirir, tekrarları reddeder
+func normalizeSerialList(serials []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var result []string
+	for _, serial := range serials {
+		serial = normalizeSerial(serial)
+		if serial == "" {
+			continue
+		}
+		if seen[serial] {
+			return nil, fmt.Errorf("seri numarası iki kez girildi: %s", serial)
+		}
+		seen[serial] = true
+		result = append(result, serial)
+	}
+	return result, nil
+}
+
+// checkSerialCount - Seri takipli üründe seri sayısı miktara eşit olmalı
+func checkSerialCount(product *Product, serials []string, amount float64) error {
+	if float64(len(serials)) != amount {
+		return fmt.Errorf("%s: %.0f adet için %d seri numarası girildi", product.Name, amount, len(serials))
+	}
+	return nil
+}
+
+// getSerial - Ürünün seri numarası kaydı (yoksa nil)
+func (s *BleveStore) getSerial(productID, serial string) *SerialNumber {
+	var record SerialNumber
+	if err := s.readJSONFile(serialsDir, serialID(productID, serial), &record); err != nil {
+		return nil
+	}
+	return &record
+}
+
+// saveSerial - Seri numarası kaydını yazar
+func (s *BleveStore) saveSerial(record *SerialNumber) error {
+	record.UpdatedAt = time.Now()
+	return s.writeJSONFile(serialsDir, record.ID, record)
+}
+
+// addEvent - Geçmişe olay ekler
+func (record *SerialNumber) addEvent(event *SerialEvent) {
+	if event.Date.IsZero() {
+		event.Date = time.Now()
+	}
+	record.History = append(record.History, event)
+}
+
+// prepareReceiveSerials - Girişteki seri numaralarını doğrular, yeni olanlar için kayıt hazırlar
+func (s *BleveStore) prepareReceiveSerials(product *Product, serials []string) ([]*SerialNumber, error) {
+	records := make([]*SerialNumber, 0, len(serials))
+	for _, serial := range serials {
+		record := s.getSerial(product.ID, serial)
+		if record != nil && record.Status == SerialInStock {
+			return nil, fmt.Errorf("seri numarası zaten stokta: %s", serial)
+		}
+		if record == nil {
+			record = &SerialNumber{
+				ID:          serialID(product.ID, serial),
+				ProductID:   product.ID,
+				ProductName: product.Name,
+				Serial:      serial,
+				CreatedAt:   time.Now(),
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// checkSerialsInStock - Seri numaralarının verilen yerde stokta olduğunu doğrular
+// Bir siparişe bağlı birimler yalnızca o siparişle (orderID) hareket edebilir.
+func (s *BleveStore) checkSerialsInStock(product *Product, serials []string, locationID, orderID string) ([]*SerialNumber, error) {
+	records := make([]*SerialNumber, 0, len(serials))
+	for _, serial := range serials {
+		record := s.getSerial(product.ID, serial)
+		if record == nil {
+			return nil, fmt.Errorf("seri numarası bulunamadı: %s", serial)
+		}
+		if record.Status != SerialInStock {
+			return nil, fmt.Errorf("seri numarası stokta değil: %s", serial)
+		}
+		if record.LocationID != "" && record.LocationID != locationID {
+			return nil, fmt.Errorf("seri numarası başka stok yerinde: %s", serial)
+		}
+		if record.OrderID != "" && record.OrderID != orderID {
+			return nil, fmt.Errorf("seri numarası başka siparişe ayrılmış: %s", serial)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// updateSerials - Kayıtlara durum/yer uygular ve olay ekler
+func (s *BleveStore) updateSerials(records []*SerialNumber, status, locationID string, event SerialEvent) error {
+	for _, record := range records {
+		record.Status = status
+		record.LocationID = locationID
+		e := event
+		record.addEvent(&e)
+		if err := s.saveSerial(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadSerials - Seri numarası kayıtlarını okur (bulunamayanları atlar)
+func (s *BleveStore) loadSerials(productID string, serials []string) []*SerialNumber {
+	var records []*SerialNumber
+	for _, serial := range serials {
+		if record := s.getSerial(productID, serial); record != nil {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// SetProductSerialTracking - Turns serial tracking on or off for a product
+// Serial tracking is only possible for products counted in pieces.
+func (s *BleveStore) SetProductSerialTracking(productID string, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, err := s.GetProduct(productID)
+	if err != nil {
+		return err
+	}
+	if enabled && QuantityDecimals(product.Unit) > 0 {
+		return fmt.Errorf("seri takibi yalnızca tam sayılı birimlerde kullanılabilir")
+	}
+
+	product.SerialTracking = enabled
+	product.UpdatedAt = time.Now()
+
+	return s.SaveProduct(product)
+}
+
+// listSerials - Tüm seri numarası kayıtları
+func (s *BleveStore) listSerials() ([]*SerialNumber, error) {
+	ids, err := s.listJSONFileIDs(serialsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []*SerialNumber{}
+	for _, id := range ids {
+		var record SerialNumber
+		if err := s.readJSONFile(serialsDir, id, &record); err != nil {
+			continue
+		}
+		records = append(records, &record)
+	}
+	return records, nil
+}
+
+// FindSerialNumbers - Looks up a serial number (across all products) with its full history
+func (s *BleveStore) FindSerialNumbers(serial string) ([]*SerialNumber, error) {
+	serial = normalizeSerial(serial)
+	if serial == "" {
+		return nil, fmt.Errorf("seri numarası boş olamaz")
+	}
+
+	records, err := s.listSerials()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*SerialNumber{}
+	for _, record := range records {
+		if record.Serial == serial {
+			result = append(result, record)
+		}
+	}
+
+	return result, nil
+}
+
+// ListProductSerials - Serial numbers of a product, optionally filtered by status
+func (s *BleveStore) ListProductSerials(productID, status string) ([]*SerialNumber, error) {
+	records, err := s.listSerials()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*SerialNumber{}
+	for _, record := range records {
+		if record.ProductID != productID {
+			continue
+		}
+		if status != "" && record.Status != status {
+			continue
+		}
+		result = append(result, record)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Serial < result[j].Serial
+	})
+
+	return result, nil
+}
+
+// validateOrderSerials - Seri takipli kalemlerde seri numaralarını doğrular
+func (s *BleveStore) validateOrderSerials(order *Order, item *OrderItem, product *Product) error {
+	serials, err := normalizeSerialList(item.SerialNumbers)
+	if err != nil {
+		return fmt.Errorf("%s: %w", item.ProductName, err)
+	}
+	item.SerialNumbers = serials
+
+	if !product.SerialTracking {
+		return nil
+	}
+	if err := checkSerialCount(product, serials, item.StockQuantity()); err != nil {
+		return err
+	}
+
+	for _, serial := range serials {
+		record := s.getSerial(product.ID, serial)
+		if record == nil {
+			return fmt.Errorf("seri numarası bulunamadı: %s", serial)
+		}
+		if record.OrderID != "" && record.OrderID != order.ID {
+			return fmt.Errorf("seri numarası başka siparişte: %s", serial)
+		}
+		// Stok yeri teslimde (StockOutEntry) doğrulanır
+		if record.OrderID != order.ID && record.Status != SerialInStock {
+			return fmt.Errorf("seri numarası stokta değil: %s", serial)
+		}
+	}
+
+	return nil
+}
+
+// assignOrderSerials - Siparişteki seri numaralarını siparişe bağlar, çıkarılanları serbest bırakır
+// Taslak ve onaylı siparişte birim yalnızca ayrılır; satış ve müşteri teslimde
+// (markOrderSerialsSold) yazılır. Satış sayılan siparişte doğrudan satış kaydedilir.
+func (s *BleveStore) assignOrderSerials(order *Order) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wanted := make(map[string]bool)
+	for _, item := range order.Items {
+		for _, serial := range item.SerialNumbers {
+			wanted[serialID(item.ProductID, serial)] = true
+		}
+	}
+
+	records, err := s.listSerials()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	for _, record := range records {
+		switch {
+		case record.OrderID == order.ID && !wanted[record.ID]:
+			record.OrderID = ""
+			record.CustomerID = ""
+			record.CustomerName = ""
+			record.addEvent(&SerialEvent{Type: SerialEventUnassigned, OrderID: order.ID, OrderTitle: order.Title})
+		case record.OrderID != order.ID && wanted[record.ID]:
+			record.OrderID = order.ID
+			if order.isSale() {
+				record.markSold(order)
+			} else {
+				record.addEvent(&SerialEvent{Type: SerialEventAssigned, OrderID: order.ID, OrderTitle: order.Title})
+			}
+		default:
+			continue
+		}
+		if err := s.saveSerial(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// markSold - Birimi siparişin müşterisine satılmış olarak işaretler
+func (record *SerialNumber) markSold(order *Order) {
+	record.CustomerID = order.CustomerID
+	record.CustomerName = order.CustomerName
+	record.addEvent(&SerialEvent{
+		Type:         SerialEventSold,
+		OrderID:      order.ID,
+		OrderTitle:   order.Title,
+		CustomerID:   order.CustomerID,
+		CustomerName: order.CustomerName,
+	})
+}
+
+// markOrderSerialsSold - Teslim edilen siparişin seri numaralarına satışı yazar; çağıran s.mu'yu tutmalıdır
+func (s *BleveStore) markOrderSerialsSold(order *Order) error {
+	for _, item := range order.Items {
+		for _, record := range s.loadSerials(item.ProductID, item.SerialNumbers) {
+			if record.OrderID != order.ID {
+				continue
+			}
+			record.markSold(order)
+			if err := s.saveSerial(record); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// releaseOrderSerials - Silinen siparişin seri numaralarını serbest bırakır
+func (s *BleveStore) releaseOrderSerials(order *Order) error {
+	released := *order
+	released.Items = nil
+	return s.assignOrderSerials(&released)
+}
+
+// ReturnSerial - Records a customer return of a sold unit
+// With restock the unit goes back into stock at the location (with a stock-in movement);
+// otherwise it is kept as "returned" (e.g. sent for warranty). If the stock-in fails the
+// unit stays "returned" and can be restocked later with a normal stock-in.
+func (s *BleveStore) ReturnSerial(productID, serial, locationID string, restock bool, note string) (*SerialNumber, error) {
+	serial = normalizeSerial(serial)
+
+	s.mu.Lock()
+	record := s.getSerial(productID, serial)
+	if record == nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("seri numarası bulunamadı: %s", serial)
+	}
+	if record.Status == SerialInStock {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("seri numarası zaten stokta: %s", serial)
+	}
+
+	record.addEvent(&SerialEvent{
+		Type:         SerialEventReturned,
+		OrderID:      record.OrderID,
+		CustomerID:   record.CustomerID,
+		CustomerName: record.CustomerName,
+		Note:         note,
+	})
+	record.Status = SerialReturned
+	record.LocationID = ""
+	record.OrderID = ""
+	record.CustomerID = ""
+	record.CustomerName = ""
+	err := s.saveSerial(record)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	if restock {
+		if note == "" {
+			note = "İade: " + serial
+		}
+		if err := s.StockInEntry(BulkStockInfo{
+			ProductID:     productID,
+			Amount:        1,
+			Note:          note,
+			LocationID:    locationID,
+			SerialNumbers: []string{serial},
+		}); err != nil {
+			return record, err
+		}
+		return s.getSerial(productID, serial), nil
+	}
+
+	return record, nil
+}
@@ -0,0 +1,80 @@
+package storage
+
+import "testing"
+
+// newSerialProduct - Stokta iki seri numaralı birimi olan ürün
+func newSerialProduct(t *testing.T, s *BleveStore) *Product {
+	t.Helper()
+
+	product, err := s.CreateProductFull("Akü 60Ah", "AKU-60", "Varta", "Elektrik", UnitPiece, 0, 1)
+	if err != nil {
+		t.Fatalf("CreateProductFull: %v", err)
+	}
+	if err := s.SetProductSerialTracking(product.ID, true); err != nil {
+		t.Fatalf("SetProductSerialTracking: %v", err)
+	}
+	if err := s.StockInEntry(BulkStockInfo{ProductID: product.ID, Amount: 2, SerialNumbers: []string{"SN1", "SN2"}}); err != nil {
+		t.Fatalf("StockInEntry: %v", err)
+	}
+	return product
+}
+
+func TestOrderSerialsSoldOnDelivery(t *testing.T) {
+	s := newTestStore(t)
+	product := newSerialProduct(t, s)
+
+	customer := NewCustomer("Ahmet Yılmaz")
+	if err := s.SaveCustomer(customer); err != nil {
+		t.Fatalf("SaveCustomer: %v", err)
+	}
+	order := NewOrderWithCustomer(customer.ID, customer.Name)
+	item := NewOrderItem(product.Name, product.OEMNumber, 1, 100000, "original")
+	item.SerialNumbers = []string{"sn1"}
+	order.Items = append(order.Items, item)
+	if err := s.SaveOrder(order); err != nil {
+		t.Fatalf("SaveOrder: %v", err)
+	}
+
+	lastEvent := func(r *SerialNumber) string { return r.History[len(r.History)-1].Type }
+
+	record := s.getSerial(product.ID, "SN1")
+	if record.OrderID != order.ID || record.CustomerID != "" || lastEvent(record) != SerialEventAssigned {
+		t.Fatalf("draft order: order %q, customer %q, event %q; want assigned without customer",
+			record.OrderID, record.CustomerID, lastEvent(record))
+	}
+
+	if err := s.SetOrderStatus(order.ID, OrderStatusConfirmed); err != nil {
+		t.Fatalf("SetOrderStatus: %v", err)
+	}
+	if err := s.DeliverOrder(order.ID, ""); err != nil {
+		t.Fatalf("DeliverOrder: %v", err)
+	}
+
+	record = s.getSerial(product.ID, "SN1")
+	if record.Status != SerialOut || record.CustomerID != customer.ID || lastEvent(record) != SerialEventSold {
+		t.Errorf("delivered order: status %q, customer %q, event %q; want out, sold to %q",
+			record.Status, record.CustomerID, lastEvent(record), customer.ID)
+	}
+	if other := s.getSerial(product.ID, "SN2"); other.Status != SerialInStock || other.OrderID != "" {
+		t.Errorf("SN2: status %q, order %q; want in stock and unassigned", other.Status, other.OrderID)
+	}
+}
+
+func TestAdjustStockRejectsSerialTracked(t *testing.T) {
+	s := newTestStore(t)
+	product := newSerialProduct(t, s)
+
+	for _, delta := range []float64{1, -1} {
+		if _, err := s.AdjustStock(product.ID, "", delta, AdjustReasonStockCount, ""); err == nil {
+			t.Errorf("AdjustStock(%v) succeeded on a serial-tracked product, want error", delta)
+		}
+	}
+
+	product, err := s.GetProduct(product.ID)
+	if err != nil {
+		t.Fatalf("GetProduct: %v", err)
+	}
+	if product.StockQuantity != 2 {
+		t.Errorf("StockQuantity = %v, want 2", product.StockQuantity)
+	}
+}
@@ -2,8 +2,10 @@ package storage
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // AppSettings represents application settings stored on disk
@@ -11,6 +13,18 @@ type AppSettings struct {
 	DeveloperMode bool   `json:"developerMode"`
 	Theme         string `json:"theme"`
 	ItemsPerPage  int    `json:"itemsPerPage"`
+
+	// TaxRates overrides DefaultCategoryTaxRates (category -> KDV %)
+	TaxRates map[string]float64 `json:"taxRates,omitempty"`
+
+	// BaseCurrency is the currency totals and reports are converted to (default TRY)
+	BaseCurrency string `json:"baseCurrency,omitempty"`
+
+	// ValuationMethod is the inventory valuation method: "fifo" or "average" (default)
+	ValuationMethod string `json:"valuationMethod,omitempty"`
+
+	// ServiceIntervals overrides DefaultServiceIntervals (category or service type -> interval)
+	ServiceIntervals map[string]ServiceInterval `json:"serviceIntervals,omitempty"`
 }
 
 // DefaultSettings returns default application settings
@@ -81,3 +95,121 @@ func IsDeveloperMode() bool {
 	settings, _ := LoadSettings()
 	return settings.DeveloperMode
 }
+
+// GetTaxRates returns KDV rates (%) per category, saved overrides applied over defaults
+func GetTaxRates() map[string]float64 {
+	rates := make(map[string]float64)
+	for category, rate := range DefaultCategoryTaxRates {
+		rates[category] = rate
+	}
+
+	settings, _ := LoadSettings()
+	for category, rate := range settings.TaxRates {
+		rates[category] = rate
+	}
+
+	return rates
+}
+
+// GetCategoryTaxRate returns the KDV rate (%) for a product category
+func GetCategoryTaxRate(category string) float64 {
+	if rate, ok := GetTaxRates()[category]; ok {
+		return rate
+	}
+	return DefaultTaxRate
+}
+
+// UpdateCategoryTaxRate sets the KDV rate (%) for a product category
+func UpdateCategoryTaxRate(category string, rate float64) error {
+	if category == "" {
+		return fmt.Errorf("category cannot be empty")
+	}
+	if rate < 0 || rate > 100 {
+		return fmt.Errorf("tax rate must be between 0 and 100")
+	}
+
+	settings, _ := LoadSettings()
+	if settings.TaxRates == nil {
+		settings.TaxRates = make(map[string]float64)
+	}
+	settings.TaxRates[category] = rate
+	return SaveSettings(settings)
+}
+
+// GetBaseCurrency returns the base currency code
+func GetBaseCurrency() string {
+	settings, _ := LoadSettings()
+	if settings.BaseCurrency == "" {
+		return CurrencyTRY
+	}
+	return settings.BaseCurrency
+}
+
+// saveBaseCurrency stores the base currency code
+// Callers go through BleveStore.UpdateBaseCurrency, which validates the code and
+// refuses the change once records priced in the previous base currency exist.
+func saveBaseCurrency(currency string) error {
+	settings, _ := LoadSettings()
+	settings.BaseCurrency = currency
+	return SaveSettings(settings)
+}
+
+// GetValuationMethod returns the configured inventory valuation method
+func GetValuationMethod() string {
+	settings, _ := LoadSettings()
+	if settings.ValuationMethod == ValuationFIFO {
+		return ValuationFIFO
+	}
+	return ValuationAverage
+}
+
+// UpdateValuationMethod sets the inventory valuation method
+func UpdateValuationMethod(method string) error {
+	if method != ValuationFIFO && method != ValuationAverage {
+		return fmt.Errorf("invalid valuation method: %q", method)
+	}
+
+	settings, _ := LoadSettings()
+	settings.ValuationMethod = method
+	return SaveSettings(settings)
+}
+
+// GetServiceIntervals returns maintenance intervals per category or service type,
+// saved overrides applied over defaults. Disabled intervals (no months and no km) are left out.
+func GetServiceIntervals() map[string]ServiceInterval {
+	intervals := make(map[string]ServiceInterval)
+	for name, interval := range DefaultServiceIntervals {
+		intervals[name] = interval
+	}
+
+	settings, _ := LoadSettings()
+	for name, interval := range settings.ServiceIntervals {
+		intervals[name] = interval
+	}
+
+	for name, interval := range intervals {
+		if interval.Months == 0 && interval.Kilometers == 0 {
+			delete(intervals, name)
+		}
+	}
+	return intervals
+}
+
+// UpdateServiceInterval sets the maintenance interval of a category or service type
+// Zero months and zero km disable the interval (also for defaults).
+func UpdateServiceInterval(name string, interval ServiceInterval) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("service type cannot be empty")
+	}
+	if interval.Months < 0 || interval.Kilometers < 0 {
+		return fmt.Errorf("service interval cannot be negative")
+	}
+
+	settings, _ := LoadSettings()
+	if settings.ServiceIntervals == nil {
+		settings.ServiceIntervals = make(map[string]ServiceInterval)
+	}
+	settings.ServiceIntervals[name] = interval
+	return SaveSettings(settings)
+}
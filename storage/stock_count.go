@@ -0,0 +1,331 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ============================================
+// Stok Sayımı - Sayım oturumları
+// Oturum bir stok yeri için açılır, ürün bazında sayılan miktarlar girilir (aynı anda
+// birden fazla ekrandan girilebilir), farklar o yerdeki stoğa göre incelenir ve onaylanır.
+// Onayda her fark için "adjust" hareketi yazılır; oturum denetim için saklanır.
+// ============================================
+
+// Stock count statuses
+const (
+	StockCountOpen      = "open"
+	StockCountCommitted = "committed"
+	StockCountCancelled = "cancelled"
+)
+
+const stockCountsDir = "stock_counts"
+
+// StockCountLine - Counted quantity of one product in a session
+type StockCountLine struct {
+	ProductID        string    `json:"product_id"`
+	ProductName      string    `json:"product_name"`
+	Unit             string    `json:"unit"`
+	CountedQuantity  float64   `json:"counted_quantity"`
+	ExpectedQuantity float64   `json:"expected_quantity"` // Stock at the location (open: current, committed: at commit)
+	Difference       float64   `json:"difference"`        // Counted - expected
+	Note             string    `json:"note,omitempty"`
+	CountedAt        time.Time `json:"counted_at"`
+	MovementID       string    `json:"movement_id,omitempty"` // Adjustment posted on commit
+}
+
+// StockCount - Stock-take session
+type StockCount struct {
+	ID              string            `json:"id"`
+	Title           string            `json:"title"`
+	LocationID      string            `json:"location_id"`
+	Note            string            `json:"note"`
+	Status          string            `json:"status"` // "open", "committed" or "cancelled"
+	Lines           []*StockCountLine `json:"lines"`
+	DifferenceCount int               `json:"difference_count"` // Lines with a non-zero difference
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+	CommittedAt     *time.Time        `json:"committed_at,omitempty"`
+	CancelledAt     *time.Time        `json:"cancelled_at,omitempty"`
+}
+
+// StartStockCount - Opens a new stock-take session
+func (s *BleveStore) StartStockCount(title, locationID, note string) (*StockCount, error) {
+	locationID, err := s.resolveLocation(locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = "Sayım " + time.Now().Format("2006-01-02")
+	}
+
+	count := &StockCount{
+		ID:         uuid.New().String(),
+		Title:      title,
+		LocationID: locationID,
+		Note:       strings.TrimSpace(note),
+		Status:     StockCountOpen,
+		Lines:      []*StockCountLine{},
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+
+	if err := s.writeJSONFile(stockCountsDir, count.ID, count); err != nil {
+		return nil, err
+	}
+
+	return count, nil
+}
+
+// stockLocation - Sayılan yer (eski kayıtlarda varsayılan yer)
+func (count *StockCount) stockLocation() string {
+	if count.LocationID == "" {
+		return DefaultLocationID
+	}
+	return count.LocationID
+}
+
+// loadStockCount - Oturumu dosyadan okur
+func (s *BleveStore) loadStockCount(id string) (*StockCount, error) {
+	var count StockCount
+	if err := s.readJSONFile(stockCountsDir, id, &count); err != nil {
+		return nil, fmt.Errorf("sayım bulunamadı: %w", err)
+	}
+	return &count, nil
+}
+
+// GetStockCount - Returns a session; open sessions show differences against current stock
+func (s *BleveStore) GetStockCount(id string) (*StockCount, error) {
+	count, err := s.loadStockCount(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if count.Status == StockCountOpen {
+		s.refreshStockCountDifferences(count)
+	}
+
+	return count, nil
+}
+
+// ListStockCounts - Lists sessions, newest first
+func (s *BleveStore) ListStockCounts() ([]*StockCount, error) {
+	ids, err := s.listJSONFileIDs(stockCountsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := []*StockCount{}
+	for _, id := range ids {
+		count, err := s.loadStockCount(id)
+		if err != nil {
+			continue
+		}
+		counts = append(counts, count)
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].CreatedAt.After(counts[j].CreatedAt)
+	})
+
+	return counts, nil
+}
+
+// refreshStockCountDifferences - Beklenen miktarları güncel stoktan yeniden hesaplar
+func (s *BleveStore) refreshStockCountDifferences(count *StockCount) {
+	count.DifferenceCount = 0
+	for _, line := range count.Lines {
+		if line.MovementID != "" {
+			// Düzeltmesi yazılmış satır: onay anındaki değerler korunur
+			count.DifferenceCount++
+			continue
+		}
+		if product, err := s.GetProduct(line.ProductID); err == nil {
+			line.ProductName = product.Name
+			line.Unit = product.Unit
+			line.ExpectedQuantity = product.QuantityAt(count.stockLocation())
+		}
+		line.Difference = math.Round((line.CountedQuantity-line.ExpectedQuantity)*quantityScale) / quantityScale
+		if math.Abs(line.Difference) >= stockTolerance {
+			count.DifferenceCount++
+		}
+	}
+}
+
+// openStockCount - Açık oturumu okur; çağıran s.mu'yu tutmalıdır
+func (s *BleveStore) openStockCount(id string) (*StockCount, error) {
+	count, err := s.loadStockCount(id)
+	if err != nil {
+		return nil, err
+	}
+	if count.Status != StockCountOpen {
+		return nil, fmt.Errorf("sayım kapalı (%s)", count.Status)
+	}
+	return count, nil
+}
+
+// SetStockCountLine - Enters or replaces the counted quantity of a product
+// Lines are keyed by product, so entries from several screens merge into one session.
+func (s *BleveStore) SetStockCountLine(countID, productID string, counted float64, note string) (*StockCount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count, err := s.openStockCount(countID)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.GetProduct(productID)
+	if err != nil {
+		return nil, fmt.Errorf("product not found: %w", err)
+	}
+
+	if counted < 0 {
+		return nil, fmt.Errorf("sayılan miktar negatif olamaz")
+	}
+	if counted > 0 {
+		if err := ValidateQuantity(counted, product.Unit); err != nil {
+			return nil, err
+		}
+	}
+
+	var line *StockCountLine
+	for _, l := range count.Lines {
+		if l.ProductID == productID {
+			line = l
+			break
+		}
+	}
+	if line != nil && line.MovementID != "" {
+		return nil, fmt.Errorf("%s için düzeltme zaten yazıldı", line.ProductName)
+	}
+	if line == nil {
+		line = &StockCountLine{ProductID: productID}
+		count.Lines = append(count.Lines, line)
+	}
+	line.CountedQuantity = counted
+	line.Note = strings.TrimSpace(note)
+	line.CountedAt = time.Now()
+
+	s.refreshStockCountDifferences(count)
+	count.UpdatedAt = time.Now()
+
+	if err := s.writeJSONFile(stockCountsDir, count.ID, count); err != nil {
+		return nil, err
+	}
+
+	return count, nil
+}
+
+// RemoveStockCountLine - Removes a product from an open session
+func (s *BleveStore) RemoveStockCountLine(countID, productID string) (*StockCount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count, err := s.openStockCount(countID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := count.Lines[:0]
+	for _, l := range count.Lines {
+		if l.ProductID != productID {
+			lines = append(lines, l)
+		}
+	}
+	count.Lines = lines
+
+	s.refreshStockCountDifferences(count)
+	count.UpdatedAt = time.Now()
+
+	if err := s.writeJSONFile(stockCountsDir, count.ID, count); err != nil {
+		return nil, err
+	}
+
+	return count, nil
+}
+
+// CommitStockCount - Posts an "adjust" movement for every difference and closes the session
+// Differences are taken against the stock at commit time. If a line fails, the lines
+// already adjusted keep their movement IDs and the session stays open for a retry.
+func (s *BleveStore) CommitStockCount(countID string) (*StockCount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count, err := s.openStockCount(countID)
+	if err != nil {
+		return nil, err
+	}
+	if len(count.Lines) == 0 {
+		return nil, fmt.Errorf("sayımda ürün yok")
+	}
+
+	s.refreshStockCountDifferences(count)
+
+	note := "Sayım: " + count.Title
+	var errors []string
+	for _, line := range count.Lines {
+		if line.MovementID != "" || math.Abs(line.Difference) < stockTolerance {
+			continue
+		}
+
+		product, err := s.GetProduct(line.ProductID)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", line.ProductName, err))
+			continue
+		}
+
+		movement, err := s.adjustStock(product, count.stockLocation(), line.Difference, AdjustReasonStockCount, note)
+		if err != nil {
+			errors = append(errors, fmt.Sprintf("%s: %v", line.ProductName, err))
+			continue
+		}
+		line.MovementID = movement.ID
+	}
+
+	count.UpdatedAt = time.Now()
+	if len(errors) == 0 {
+		now := time.Now()
+		count.Status = StockCountCommitted
+		count.CommittedAt = &now
+	}
+
+	if err := s.writeJSONFile(stockCountsDir, count.ID, count); err != nil {
+		return nil, err
+	}
+
+	if len(errors) > 0 {
+		return count, fmt.Errorf("sayım tamamlanamadı: %s", strings.Join(errors, "; "))
+	}
+
+	return count, nil
+}
+
+// CancelStockCount - Closes an open session without touching stock
+func (s *BleveStore) CancelStockCount(countID string) (*StockCount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count, err := s.openStockCount(countID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	count.Status = StockCountCancelled
+	count.CancelledAt = &now
+	count.UpdatedAt = now
+
+	if err := s.writeJSONFile(stockCountsDir, count.ID, count); err != nil {
+		return nil, err
+	}
+
+	return count, nil
+}
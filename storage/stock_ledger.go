@@ -0,0 +1,328 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ============================================
+// Stok Defteri - StockMovement kayıtlarından stok seviyesi
+// Girişler (+), çıkışlar (-) ve işaretli düzeltmeler tarih sırasına göre toplanır.
+// Mutabakat, defterden hesaplanan miktarı Product.StockQuantity ile karşılaştırır.
+// ============================================
+
+// stockTolerance - Ondalık miktar karşılaştırmalarında kabul edilen fark
+const stockTolerance = 0.0005
+
+// StockLevel - Stock level of one product rebuilt from the ledger
+type StockLevel struct {
+	ProductID      string    `json:"product_id"`
+	ProductName    string    `json:"product_name"`
+	Unit           string    `json:"unit"`
+	Quantity       float64   `json:"quantity"`
+	MovementCount  int       `json:"movement_count"`
+	LastMovementAt time.Time `json:"last_movement_at"`
+
+	locations map[string]float64 // Quantity per location ID
+}
+
+// StockMismatch - Product whose stored stock differs from the ledger
+type StockMismatch struct {
+	ProductID      string  `json:"product_id"`
+	ProductName    string  `json:"product_name"`
+	Unit           string  `json:"unit"`
+	StoredQuantity float64 `json:"stored_quantity"` // Product.StockQuantity
+	LedgerQuantity float64 `json:"ledger_quantity"` // Sum of movements
+	Difference     float64 `json:"difference"`      // Stored - ledger
+	Adjusted       bool    `json:"adjusted"`        // Correcting adjustment posted
+}
+
+// StockReconciliation - Result of comparing stored stock with the ledger
+type StockReconciliation struct {
+	CheckedAt     time.Time        `json:"checked_at"`
+	ProductCount  int              `json:"product_count"`
+	Mismatches    []*StockMismatch `json:"mismatches"`
+	AdjustedCount int              `json:"adjusted_count"`
+	Errors        []string         `json:"errors,omitempty"`
+}
+
+// QuantityDelta - Hareketin stok miktarına etkisi (+ artış, - azalış)
+func (m *StockMovement) QuantityDelta() float64 {
+	switch m.MovementType {
+	case MovementTypeIn:
+		return m.Amount
+	case MovementTypeOut:
+		return -m.Amount
+	case MovementTypeAdjust:
+		return m.Amount
+	}
+	return 0
+}
+
+// newAdjustMovement - Ürün için işaretli düzeltme hareketi (ortalama maliyetle değerlenir)
+func newAdjustMovement(product *Product, delta float64, reason, note string) *StockMovement {
+	return &StockMovement{
+		ID:           uuid.New().String(),
+		ProductID:    product.ID,
+		ProductName:  product.Name,
+		MovementType: MovementTypeAdjust,
+		Amount:       delta,
+		Reason:       reason,
+		Note:         note,
+		Date:         time.Now(),
+		UnitCost:     product.AverageCost,
+		BaseUnit:     product.Unit,
+	}
+}
+
+// ledgerQuantities - Hareketleri until'a kadar (dahil) ürün bazında toplar
+func (s *BleveStore) ledgerQuantities(until time.Time) (map[string]*StockLevel, error) {
+	movements, err := s.ListStockMovements()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.toCurrentUnits(movements); err != nil {
+		return nil, err
+	}
+
+	levels := make(map[string]*StockLevel)
+	for _, m := range movements {
+		if !until.IsZero() && m.Date.After(until) {
+			continue
+		}
+
+		level, ok := levels[m.ProductID]
+		if !ok {
+			level = &StockLevel{ProductID: m.ProductID, ProductName: m.ProductName, locations: make(map[string]float64)}
+			levels[m.ProductID] = level
+		}
+		level.Quantity += m.QuantityDelta()
+
+		// Yer bilgisi olmayan eski hareketler varsayılan yere sayılır
+		locationID := m.LocationID
+		if locationID == "" {
+			locationID = DefaultLocationID
+		}
+		if m.MovementType == MovementTypeTransfer {
+			level.locations[locationID] -= m.Amount
+			level.locations[m.ToLocationID] += m.Amount
+		} else {
+			level.locations[locationID] += m.QuantityDelta()
+		}
+		level.MovementCount++
+		if m.Date.After(level.LastMovementAt) {
+			level.LastMovementAt = m.Date
+		}
+	}
+
+	// Kayan nokta artıklarını temizle
+	for _, level := range levels {
+		level.Quantity = math.Round(level.Quantity*quantityScale) / quantityScale
+		for locationID, quantity := range level.locations {
+			level.locations[locationID] = math.Round(quantity*quantityScale) / quantityScale
+		}
+	}
+
+	return levels, nil
+}
+
+// GetStockLevelsAt - Rebuilds every product's stock level at the given time from the ledger
+// Products created after that time are left out; products without movements show zero.
+func (s *BleveStore) GetStockLevelsAt(at time.Time) ([]*StockLevel, error) {
+	levels, err := s.ledgerQuantities(at)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := s.ListProducts()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*StockLevel{}
+	for _, p := range products {
+		level, ok := levels[p.ID]
+		if !ok {
+			if !at.IsZero() && p.CreatedAt.After(at) {
+				continue
+			}
+			level = &StockLevel{ProductID: p.ID}
+		}
+		level.ProductName = p.Name
+		level.Unit = p.Unit
+		result = append(result, level)
+		delete(levels, p.ID)
+	}
+
+	// Silinmiş ürünlerin hareketleri
+	for _, level := range levels {
+		result = append(result, level)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ProductName < result[j].ProductName
+	})
+
+	return result, nil
+}
+
+// ReconcileStock - Compares each product's StockQuantity with the ledger and lists mismatches
+// With applyCorrections, an "adjust" movement for the difference is posted at every location
+// whose LocationStock differs from its ledger, so the ledger matches the stored quantities
+// (the stored quantities are not changed). Typical mismatches are products whose stock was
+// entered before every change went through the ledger.
+func (s *BleveStore) ReconcileStock(applyCorrections bool) (*StockReconciliation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	levels, err := s.ledgerQuantities(time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := s.ListProducts()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StockReconciliation{
+		CheckedAt:    time.Now(),
+		ProductCount: len(products),
+		Mismatches:   []*StockMismatch{},
+	}
+
+	for _, p := range products {
+		var ledger float64
+		ledgerByLocation := map[string]float64{}
+		if level, ok := levels[p.ID]; ok {
+			ledger = level.Quantity
+			ledgerByLocation = level.locations
+		}
+
+		// Yer bazında fark (toplam tutsa da yerler ayrışmış olabilir)
+		locationDiffs := map[string]float64{DefaultLocationID: 0}
+		for locationID := range ledgerByLocation {
+			locationDiffs[locationID] = 0
+		}
+		for locationID := range p.LocationStock {
+			locationDiffs[locationID] = 0
+		}
+		drift := false
+		for locationID := range locationDiffs {
+			d := math.Round((p.QuantityAt(locationID)-ledgerByLocation[locationID])*quantityScale) / quantityScale
+			locationDiffs[locationID] = d
+			if math.Abs(d) >= stockTolerance {
+				drift = true
+			}
+		}
+
+		diff := p.StockQuantity - ledger
+		if math.Abs(diff) < stockTolerance && !drift {
+			continue
+		}
+
+		mismatch := &StockMismatch{
+			ProductID:      p.ID,
+			ProductName:    p.Name,
+			Unit:           p.Unit,
+			StoredQuantity: p.StockQuantity,
+			LedgerQuantity: ledger,
+			Difference:     math.Round(diff*quantityScale) / quantityScale,
+		}
+		result.Mismatches = append(result.Mismatches, mismatch)
+
+		if !applyCorrections {
+			continue
+		}
+		locationIDs := make([]string, 0, len(locationDiffs))
+		for locationID, d := range locationDiffs {
+			if math.Abs(d) >= stockTolerance {
+				locationIDs = append(locationIDs, locationID)
+			}
+		}
+		sort.Strings(locationIDs)
+
+		adjusted := true
+		for _, locationID := range locationIDs {
+			movement := newAdjustMovement(p, locationDiffs[locationID], AdjustReasonReconciliation, "Stok mutabakatı")
+			movement.LocationID = locationID
+			if err := s.SaveStockMovement(movement); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s (%s): %v", p.Name, locationID, err))
+				adjusted = false
+			}
+		}
+		if adjusted {
+			mismatch.Adjusted = true
+			result.AdjustedCount++
+		}
+	}
+
+	sort.Slice(result.Mismatches, func(i, j int) bool {
+		return math.Abs(result.Mismatches[i].Difference) > math.Abs(result.Mismatches[j].Difference)
+	})
+
+	return result, nil
+}
+
+// AdjustStock - Changes stock at a location by a signed amount and records an "adjust" movement
+// Unlike StockOut, an adjustment may take stock below zero; reason is required. Serial-tracked
+// products cannot be adjusted (the units would not match their serial numbers); use a
+// stock-in or stock-out with serial numbers instead.
+func (s *BleveStore) AdjustStock(productID, locationID string, delta float64, reason, note string) (*StockMovement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, err := s.GetProduct(productID)
+	if err != nil {
+		return nil, fmt.Errorf("product not found: %w", err)
+	}
+
+	locationID, err = s.resolveLocation(locationID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.adjustStock(product, locationID, delta, reason, note)
+}
+
+// adjustStock - AdjustStock gövdesi; çağıran s.mu'yu tutmalıdır
+func (s *BleveStore) adjustStock(product *Product, locationID string, delta float64, reason, note string) (*StockMovement, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("düzeltme nedeni girilmeli")
+	}
+	if product.SerialTracking {
+		return nil, fmt.Errorf("seri takipli üründe düzeltme yapılamaz; seri numaralarıyla giriş veya çıkış yapın")
+	}
+	if math.Abs(delta) < stockTolerance {
+		return nil, fmt.Errorf("düzeltme miktarı sıfır olamaz")
+	}
+	if err := ValidateQuantity(math.Abs(delta), product.Unit); err != nil {
+		return nil, err
+	}
+
+	var allocations []LotAllocation
+	if product.LotTracking && delta < 0 {
+		allocations, _ = product.consumeLots(locationID, -delta, "")
+	}
+
+	product.applyStockDelta(locationID, delta)
+	product.UpdatedAt = time.Now()
+
+	if err := s.SaveProduct(product); err != nil {
+		return nil, fmt.Errorf("stok güncellenemedi: %w", err)
+	}
+
+	movement := newAdjustMovement(product, delta, strings.TrimSpace(reason), note)
+	movement.LocationID = locationID
+	movement.LotAllocations = allocations
+	if err := s.SaveStockMovement(movement); err != nil {
+		return nil, err
+	}
+
+	return movement, nil
+}
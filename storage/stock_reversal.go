@@ -0,0 +1,243 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ============================================
+// Stok Hareketi İptali
+// Hareketler silinmez; iptal, ters yönde bağlı bir karşı hareket yazar,
+// StockQuantity'yi geri alır ve orijinali "iptal edildi" olarak işaretler.
+// Raporlar ve değerleme iptal edilen çifti hiç olmamış sayar.
+// ============================================
+
+// IsReversed - Hareket iptal edilmiş mi
+func (m *StockMovement) IsReversed() bool {
+	return m.ReversedBy != ""
+}
+
+// isReversedAt - Hareket verilen anda (dahil) iptal edilmiş miydi; at sıfırsa güncel durum
+func (m *StockMovement) isReversedAt(at time.Time) bool {
+	if !m.IsReversed() {
+		return false
+	}
+	if at.IsZero() || m.ReversedAt == nil {
+		return true
+	}
+	return !m.ReversedAt.After(at)
+}
+
+// reverseStockInCost - İptal edilen girişi ağırlıklı ortalamadan çıkarır
+// Kalan miktar veya değer sıfıra düşerse ortalama değişmez.
+func (p *Product) reverseStockInCost(amount float64, unitCost Money) {
+	if unitCost <= 0 {
+		return
+	}
+
+	remaining := p.StockQuantity - amount
+	total := p.AverageCost.MulQuantity(p.StockQuantity) - unitCost.MulQuantity(amount)
+	if remaining <= 0 || total <= 0 {
+		return
+	}
+	p.AverageCost = total.DivQuantity(remaining)
+}
+
+// deleteStockMovement - Hareketi index'ten ve dosyadan siler (yalnızca geri alma için)
+func (s *BleveStore) deleteStockMovement(id string) error {
+	if err := s.index.Delete("stok_hareket_" + id); err != nil {
+		return fmt.Errorf("index silme hatası: %w", err)
+	}
+	err := os.Remove(filepath.Join(s.dataPath, "stock_movements", id+".json"))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("dosya silme hatası: %w", err)
+	}
+	return nil
+}
+
+// ReverseStockMovement - Reverses a movement with a linked counter-movement
+// "in" is reversed by an "out", "out" by an "in" and "adjust" by an opposite "adjust".
+// Stock quantity, counter-movement and the original's reversed mark are written together;
+// if a later step fails the earlier ones are rolled back. Stock-outs of order deliveries
+// and stock-ins of purchase order receipts cannot be reversed on their own.
+func (s *BleveStore) ReverseStockMovement(movementID, note string) (*StockMovement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	original, err := s.GetStockMovement(movementID)
+	if err != nil {
+		return nil, err
+	}
+	// Sipariş teslimi tek başına geri alınırsa sipariş teslim edilmiş ve satış sayılmaya devam eder
+	if original.OrderID != "" && original.MovementType == MovementTypeOut {
+		return nil, fmt.Errorf("sipariş teslimine ait stok çıkışı iptal edilemez")
+	}
+	// Mal kabulü geri alınırsa satın alma siparişinin teslim alınan miktarı tutmaz
+	if original.PurchaseOrderID != "" && original.MovementType == MovementTypeIn {
+		return nil, fmt.Errorf("satın alma mal kabulüne ait stok girişi iptal edilemez; düzeltme veya iade kaydı kullanın")
+	}
+
+	return s.reverseStockMovement(movementID, note)
+}
+
+// reverseStockMovement - ReverseStockMovement gövdesi; çağıran s.mu'yu tutmalıdır
+func (s *BleveStore) reverseStockMovement(movementID, note string) (*StockMovement, error) {
+	original, err := s.GetStockMovement(movementID)
+	if err != nil {
+		return nil, err
+	}
+	if original.IsReversed() {
+		return nil, fmt.Errorf("hareket zaten iptal edilmiş")
+	}
+	if original.ReversalOf != "" {
+		return nil, fmt.Errorf("iptal hareketi tekrar iptal edilemez")
+	}
+
+	product, err := s.GetProduct(original.ProductID)
+	if err != nil {
+		return nil, fmt.Errorf("product not found: %w", err)
+	}
+	if original.BaseUnit != "" && original.BaseUnit != product.Unit {
+		return nil, fmt.Errorf("hareket %s biriminde kaydedilmiş; birim değişikliğinden önceki hareketler iptal edilemez", original.BaseUnit)
+	}
+	previous := product.clone()
+
+	if note == "" {
+		note = "İptal"
+		if original.Note != "" {
+			note += ": " + original.Note
+		}
+	}
+
+	counter := &StockMovement{
+		ID:          uuid.New().String(),
+		ProductID:   original.ProductID,
+		ProductName: product.Name,
+		Amount:      original.Amount,
+		UnitCost:    original.UnitCost,
+		Note:        note,
+		Date:        time.Now(),
+		LocationID:  original.LocationID,
+		Unit:        original.Unit,
+		UnitAmount:  original.UnitAmount,
+		BaseUnit:    product.Unit,
+		ReversalOf:  original.ID,
+	}
+	if counter.LocationID == "" {
+		counter.LocationID = DefaultLocationID
+	}
+
+	// Seri numaraları: geri alınacak birimlerin durumunu doğrula
+	var serials []*SerialNumber
+	serialStatus, serialLocation := SerialInStock, counter.LocationID
+	if product.SerialTracking && len(original.SerialNumbers) > 0 {
+		switch original.MovementType {
+		case MovementTypeIn:
+			serials, err = s.checkSerialsInStock(product, original.SerialNumbers, counter.LocationID, "")
+			serialStatus, serialLocation = SerialOut, ""
+		case MovementTypeTransfer:
+			serials, err = s.checkSerialsInStock(product, original.SerialNumbers, original.ToLocationID, "")
+			serialLocation = original.LocationID
+		case MovementTypeOut:
+			serials = s.loadSerials(product.ID, original.SerialNumbers)
+			for _, serial := range serials {
+				if serial.Status != SerialOut {
+					return nil, fmt.Errorf("seri numarası stoğa geri alınamaz: %s", serial.Serial)
+				}
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		counter.SerialNumbers = original.SerialNumbers
+	}
+
+	delta := -original.QuantityDelta()
+	switch original.MovementType {
+	case MovementTypeIn:
+		if available := product.QuantityAt(counter.LocationID); available+delta < -stockTolerance {
+			return nil, fmt.Errorf("insufficient stock: available %.2f, reversal needs %.2f", available, original.Amount)
+		}
+		counter.MovementType = MovementTypeOut
+		product.reverseStockInCost(original.Amount, original.UnitCost)
+		if product.LotTracking && original.LotNumber != "" {
+			allocations, err := product.consumeLots(counter.LocationID, original.Amount, original.LotNumber)
+			if err != nil {
+				return nil, err
+			}
+			counter.LotAllocations = allocations
+		}
+	case MovementTypeOut:
+		counter.MovementType = MovementTypeIn
+		product.restoreLots(counter.LocationID, original.LotAllocations, time.Now())
+		counter.LotAllocations = original.LotAllocations
+	case MovementTypeAdjust:
+		counter.MovementType = MovementTypeAdjust
+		counter.Amount = -original.Amount
+		counter.Reason = AdjustReasonReversal
+		if original.Amount < 0 {
+			product.restoreLots(counter.LocationID, original.LotAllocations, time.Now())
+			counter.LotAllocations = original.LotAllocations
+		} else if product.LotTracking {
+			counter.LotAllocations, _ = product.consumeLots(counter.LocationID, original.Amount, "")
+		}
+	case MovementTypeTransfer:
+		// Hedef yerden kaynağa geri aktar
+		if available := product.QuantityAt(original.ToLocationID); available < original.Amount-stockTolerance {
+			return nil, fmt.Errorf("insufficient stock: available %.2f, reversal needs %.2f", available, original.Amount)
+		}
+		counter.MovementType = MovementTypeTransfer
+		counter.LocationID = original.ToLocationID
+		counter.ToLocationID = original.LocationID
+		for _, a := range original.LotAllocations {
+			if _, err := product.consumeLots(counter.LocationID, a.Quantity, a.LotNumber); err != nil {
+				return nil, err
+			}
+		}
+		product.restoreLots(counter.ToLocationID, original.LotAllocations, time.Now())
+		counter.LotAllocations = original.LotAllocations
+		product.applyStockDelta(counter.LocationID, -original.Amount)
+		product.applyStockDelta(counter.ToLocationID, original.Amount)
+	default:
+		return nil, fmt.Errorf("bilinmeyen hareket tipi: %q", original.MovementType)
+	}
+
+	if delta != 0 {
+		product.applyStockDelta(counter.LocationID, delta)
+	}
+	product.UpdatedAt = time.Now()
+
+	if err := s.SaveProduct(product); err != nil {
+		return nil, fmt.Errorf("stok güncellenemedi: %w", err)
+	}
+
+	if err := s.SaveStockMovement(counter); err != nil {
+		s.SaveProduct(previous)
+		return nil, err
+	}
+
+	reversedAt := counter.Date
+	original.ReversedBy = counter.ID
+	original.ReversedAt = &reversedAt
+	if err := s.SaveStockMovement(original); err != nil {
+		s.deleteStockMovement(counter.ID)
+		s.SaveProduct(previous)
+		return nil, err
+	}
+
+	if err := s.updateSerials(serials, serialStatus, serialLocation, SerialEvent{
+		Type:       SerialEventReversed,
+		Date:       counter.Date,
+		MovementID: counter.ID,
+		LocationID: serialLocation,
+		Note:       note,
+	}); err != nil {
+		return counter, err
+	}
+
+	return counter, nil
+}
@@ -0,0 +1,296 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// ============================================
+// Birim Dönüşümleri
+// Ürün stoğu her zaman temel birimde (Product.Unit) tutulur. Alış ve satış için
+// ürüne özel dönüşümler tanımlanır (örn: 1 kutu = 12 adet). Hareketler ve sipariş
+// kalemleri girildikleri birimi saklar; miktar temel birime çevrilerek işlenir.
+//
+// Hareketler kayıt anındaki temel birimi (BaseUnit) taşır; temel birim sonradan
+// değişirse defter ve değerleme miktarları güncel birime çevirir.
+// ============================================
+
+// UnitConversion - 1 Unit = Factor × product base unit
+type UnitConversion struct {
+	Unit   string  `json:"unit"`
+	Factor float64 `json:"factor"`
+}
+
+// isValidUnit - Birim GetUnits listesinde mi
+func isValidUnit(unit string) bool {
+	for _, u := range GetUnits() {
+		if u == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// UnitFactor - 1 birimin temel birim karşılığı (temel birim veya boş için 1)
+func (p *Product) UnitFactor(unit string) (float64, bool) {
+	if unit == "" || unit == p.Unit {
+		return 1, true
+	}
+	for _, c := range p.UnitConversions {
+		if c.Unit == unit {
+			return c.Factor, true
+		}
+	}
+	return 0, false
+}
+
+// ToBaseQuantity - Verilen birimdeki miktarı temel birime çevirir
+// Sonuç temel birimin kuralına uymalı (örn: temel birim kutu ise 6 adet satılamaz).
+func (p *Product) ToBaseQuantity(quantity float64, unit string) (float64, error) {
+	factor, ok := p.UnitFactor(unit)
+	if !ok {
+		return 0, fmt.Errorf("%s için %q biriminden %q birimine dönüşüm tanımlı değil", p.Name, unit, p.Unit)
+	}
+	base := math.Round(quantity*factor*quantityScale) / quantityScale
+	if err := ValidateQuantity(base, p.Unit); err != nil {
+		return 0, fmt.Errorf("%.3f %s = %.3f %s: %w", quantity, unit, base, p.Unit, err)
+	}
+	return base, nil
+}
+
+// movementFactor - Hareket miktarını güncel temel birime çeviren katsayı
+// BaseUnit'i olmayan (eski) hareketler güncel birimde kabul edilir.
+func (p *Product) movementFactor(m *StockMovement) float64 {
+	if p == nil || m.BaseUnit == "" || m.BaseUnit == p.Unit {
+		return 1
+	}
+	if factor, ok := p.UnitFactor(m.BaseUnit); ok && factor > 0 {
+		return factor
+	}
+	return 1
+}
+
+// StockQuantity - Kalemin stoktan düşülen miktarı (temel birimde)
+// Dönüşüm öncesi kayıtlarda ve katalog dışı kalemlerde Quantity döner.
+func (item *OrderItem) StockQuantity() float64 {
+	if item.BaseQuantity > 0 {
+		return item.BaseQuantity
+	}
+	return item.Quantity
+}
+
+// baseQuantityFor - Kalem miktarı ürünün güncel temel biriminde
+// Kalem kendi biriminden çevrilir; böylece ürünün birimi sonradan değişse de doğru kalır.
+func (item *OrderItem) baseQuantityFor(p *Product) float64 {
+	if p != nil {
+		if factor, ok := p.UnitFactor(item.Unit); ok {
+			return item.Quantity * factor
+		}
+	}
+	return item.StockQuantity()
+}
+
+// toCurrentUnits - Hareket miktar ve maliyetlerini ürünlerin güncel temel birimine çevirir
+// Yalnızca okunmuş kopyalar üzerinde kullanılır (defter ve değerleme); kayıtlar değişmez.
+func (s *BleveStore) toCurrentUnits(movements []*StockMovement) error {
+	byID, err := s.productsByID()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range movements {
+		factor := byID[m.ProductID].movementFactor(m)
+		if factor == 1 {
+			continue
+		}
+		m.Amount = math.Round(m.Amount*factor*quantityScale) / quantityScale
+		m.UnitCost = m.UnitCost.DivQuantity(factor)
+		m.BaseUnit = byID[m.ProductID].Unit
+	}
+	return nil
+}
+
+// SetProductUnits - Sets a product's unit conversions and default purchase / sale units
+func (s *BleveStore) SetProductUnits(productID string, conversions []UnitConversion, purchaseUnit, saleUnit string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	product, err := s.GetProduct(productID)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	var cleaned []UnitConversion
+	for _, c := range conversions {
+		c.Unit = strings.TrimSpace(c.Unit)
+		if !isValidUnit(c.Unit) {
+			return fmt.Errorf("geçersiz birim: %q", c.Unit)
+		}
+		if c.Unit == product.Unit {
+			return fmt.Errorf("temel birim (%s) için dönüşüm girilemez", c.Unit)
+		}
+		if c.Factor <= 0 {
+			return fmt.Errorf("%s dönüşüm katsayısı sıfırdan büyük olmalı", c.Unit)
+		}
+		if seen[c.Unit] {
+			return fmt.Errorf("%s için birden fazla dönüşüm girildi", c.Unit)
+		}
+		seen[c.Unit] = true
+		cleaned = append(cleaned, c)
+	}
+	product.UnitConversions = cleaned
+
+	for _, u := range []string{purchaseUnit, saleUnit} {
+		if _, ok := product.UnitFactor(u); !ok {
+			return fmt.Errorf("%s için %q dönüşümü tanımlı değil", product.Name, u)
+		}
+	}
+	product.PurchaseUnit = purchaseUnit
+	product.SaleUnit = saleUnit
+	product.UpdatedAt = time.Now()
+
+	return s.SaveProduct(product)
+}
+
+// changeBaseUnit - Temel birimi değiştirir ve stok, parti, maliyet ve dönüşümleri yeni birime çevirir
+// Stok varsa yeni birim için dönüşüm tanımlı olmalı. Hareketler kendi BaseUnit'leriyle
+// kaldığı için defter geçmişi değişmez.
+func (p *Product) changeBaseUnit(unit string) error {
+	if unit == p.Unit {
+		return nil
+	}
+	if p.SerialTracking && QuantityDecimals(unit) > 0 {
+		return fmt.Errorf("seri takipli ürün ondalıklı birime geçirilemez")
+	}
+
+	factor, ok := p.UnitFactor(unit)
+	if !ok {
+		if math.Abs(p.StockQuantity) >= stockTolerance {
+			return fmt.Errorf("%s stokta var; %s biriminden %s birimine dönüşüm tanımlanmadan birim değiştirilemez", p.Name, p.Unit, unit)
+		}
+		// Stok yok: eski dönüşümler yeni birime göre anlamsız
+		p.UnitConversions = nil
+		p.PurchaseUnit = ""
+		p.SaleUnit = ""
+		p.Unit = unit
+		return nil
+	}
+
+	convert := func(q float64) float64 {
+		return math.Round(q/factor*quantityScale) / quantityScale
+	}
+
+	if len(p.LocationStock) == 0 {
+		p.applyStockDelta(DefaultLocationID, 0) // Eski kaydı yer bazına taşır
+	}
+
+	// Her yerdeki stok yeni birimde kurala uymalı (örn: 30 adet 12'lik kutuya bölünmez)
+	converted := make(map[string]float64, len(p.LocationStock))
+	for id, q := range p.LocationStock {
+		c := convert(q)
+		if err := ValidateQuantity(math.Abs(c), unit); err != nil {
+			return fmt.Errorf("stok (%.3f %s) yeni birime tam çevrilemiyor: %w", q, p.Unit, err)
+		}
+		converted[id] = c
+	}
+
+	oldUnit := p.Unit
+	p.StockQuantity = 0
+	for id, q := range converted {
+		p.LocationStock[id] = q
+		p.StockQuantity += q
+	}
+	p.StockQuantity = math.Round(p.StockQuantity*quantityScale) / quantityScale
+	for _, lot := range p.Lots {
+		lot.Quantity = convert(lot.Quantity)
+	}
+	p.ReservedQuantity = convert(p.ReservedQuantity)
+	p.ReorderPoint = convert(p.ReorderPoint)
+	p.ReorderTarget = convert(p.ReorderTarget)
+	if p.CriticalStock > 0 {
+		p.CriticalStock = int(math.Max(1, math.Ceil(float64(p.CriticalStock)/factor)))
+	}
+	for id, level := range p.LocationCritical {
+		p.LocationCritical[id] = int(math.Max(1, math.Ceil(float64(level)/factor)))
+	}
+
+	// Birim maliyetler yeni birim başına
+	p.AverageCost = p.AverageCost.MulRate(factor)
+	p.LastCost = p.LastCost.MulRate(factor)
+	if p.PurchaseUnit == "" {
+		p.PurchaseUnit = oldUnit // Alış fiyatı eski birim başınaydı
+	}
+
+	// Dönüşümleri yeni temel birime göre yeniden hesapla
+	conversions := []UnitConversion{{Unit: oldUnit, Factor: 1 / factor}}
+	for _, c := range p.UnitConversions {
+		if c.Unit == unit {
+			continue
+		}
+		conversions = append(conversions, UnitConversion{Unit: c.Unit, Factor: c.Factor / factor})
+	}
+	p.UnitConversions = conversions
+	if p.SaleUnit == unit {
+		p.SaleUnit = ""
+	}
+	if p.PurchaseUnit == unit {
+		p.PurchaseUnit = ""
+	}
+
+	p.Unit = unit
+	return nil
+}
+
+// stampMovementBaseUnit - Temel birimi boş (eski) hareketlere birim değişikliği öncesi birimi yazar
+func (s *BleveStore) stampMovementBaseUnit(productID, unit string) error {
+	movements, err := s.GetStockMovements(productID, time.Time{}, time.Time{})
+	if err != nil {
+		return err
+	}
+	for _, m := range movements {
+		if m.BaseUnit != "" {
+			continue
+		}
+		m.BaseUnit = unit
+		if err := s.SaveStockMovement(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// entryQuantity - Girilen miktarı birim kuralına göre doğrulayıp temel birime çevirir
+// Boş birim temel birim sayılır; birimin izin verdiğinden fazla ondalık hata döner
+// (2,5 adet kesilmez). Dönen katsayı 1 girilen birimin temel birim karşılığıdır.
+func (p *Product) entryQuantity(amount float64, unit string) (float64, float64, error) {
+	if unit == "" || unit == p.Unit {
+		if err := ValidateQuantity(amount, p.Unit); err != nil {
+			return 0, 0, err
+		}
+		return math.Round(amount*quantityScale) / quantityScale, 1, nil
+	}
+	factor, ok := p.UnitFactor(unit)
+	if !ok {
+		return 0, 0, fmt.Errorf("%s için %q biriminden %q birimine dönüşüm tanımlı değil", p.Name, unit, p.Unit)
+	}
+	if err := ValidateQuantity(amount, unit); err != nil {
+		return 0, 0, err
+	}
+	base, err := p.ToBaseQuantity(amount, unit)
+	if err != nil {
+		return 0, 0, err
+	}
+	return base, factor, nil
+}
+
+// setEnteredUnit - Hareketin girildiği birimi kaydeder (temel birim dışında)
+func (m *StockMovement) setEnteredUnit(unit string, factor float64) {
+	if factor == 1 {
+		return
+	}
+	m.Unit = unit
+	m.UnitAmount = math.Round(m.Amount/factor*quantityScale) / quantityScale
+}
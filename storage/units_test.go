@@ -0,0 +1,67 @@
+package storage
+
+import "testing"
+
+func TestEntryQuantity(t *testing.T) {
+	product := &Product{
+		Name:            "Motor yağı",
+		Unit:            UnitLitre,
+		UnitConversions: []UnitConversion{{Unit: UnitBox, Factor: 4}},
+	}
+
+	tests := []struct {
+		amount     float64
+		unit       string
+		wantAmount float64
+		wantFactor float64
+		wantErr    bool
+	}{
+		{amount: 2.5, unit: "", wantAmount: 2.5, wantFactor: 1},
+		{amount: 3, unit: UnitLitre, wantAmount: 3, wantFactor: 1},
+		{amount: 2, unit: UnitBox, wantAmount: 8, wantFactor: 4},
+		{amount: 2.25, unit: "", wantErr: true},
+		{amount: 1.5, unit: UnitBox, wantErr: true},
+		{amount: 0, unit: "", wantErr: true},
+		{amount: -1, unit: UnitBox, wantErr: true},
+		{amount: 1, unit: UnitPacket, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		amount, factor, err := product.entryQuantity(tt.amount, tt.unit)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("entryQuantity(%v, %q) = %v, %v, want error", tt.amount, tt.unit, amount, factor)
+			}
+			continue
+		}
+		if err != nil || amount != tt.wantAmount || factor != tt.wantFactor {
+			t.Errorf("entryQuantity(%v, %q) = %v, %v, %v, want %v, %v", tt.amount, tt.unit, amount, factor, err, tt.wantAmount, tt.wantFactor)
+		}
+	}
+}
+
+func TestStockEntryRejectsFractionalPieces(t *testing.T) {
+	s := newTestStore(t)
+	product, err := s.CreateProductFull("Buji", "BJ-1", "NGK", "Ateşleme", UnitPiece, 0, 1)
+	if err != nil {
+		t.Fatalf("CreateProductFull: %v", err)
+	}
+
+	if err := s.StockInEntry(BulkStockInfo{ProductID: product.ID, Amount: 2.5}); err == nil {
+		t.Error("StockInEntry(2.5 adet) succeeded, want error")
+	}
+	if err := s.StockInEntry(BulkStockInfo{ProductID: product.ID, Amount: 3}); err != nil {
+		t.Fatalf("StockInEntry: %v", err)
+	}
+	if err := s.StockOutEntry(BulkStockInfo{ProductID: product.ID, Amount: 1.5}); err == nil {
+		t.Error("StockOutEntry(1.5 adet) succeeded, want error")
+	}
+
+	product, err = s.GetProduct(product.ID)
+	if err != nil {
+		t.Fatalf("GetProduct: %v", err)
+	}
+	if product.StockQuantity != 3 {
+		t.Errorf("StockQuantity = %v, want 3", product.StockQuantity)
+	}
+}
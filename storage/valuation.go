@@ -0,0 +1,332 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ============================================
+// Inventory Valuation - Stok değerleme
+// StockMovement geçmişi (maliyetlerle) tarih sırasına göre yeniden oynatılır.
+//
+//   - FIFO: her giriş bir maliyet katmanı açar, çıkışlar en eski katmandan tüketilir
+//   - Ağırlıklı ortalama: her girişte ortalama maliyet yeniden hesaplanır, çıkışlar
+//     o anki ortalamadan değerlenir
+//
+// Maliyeti girilmemiş girişler o anki ortalama (FIFO'da son bilinen) maliyetle değerlenir.
+// Stok eksiye düşerse eksik miktar son bilinen maliyetle giderleştirilir; sonraki girişler
+// önce bu açığı kapatır.
+// ============================================
+
+// Valuation methods
+const (
+	ValuationFIFO    = "fifo"
+	ValuationAverage = "average"
+)
+
+// ProductValuation - Quantity and value of one product at a date
+type ProductValuation struct {
+	ProductID   string  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Quantity    float64 `json:"quantity"`
+	Value       Money   `json:"value"`
+	UnitCost    Money   `json:"unit_cost"` // Value / Quantity
+}
+
+// InventoryValuation - Value of stock on hand at a date
+type InventoryValuation struct {
+	Date       string              `json:"date"`
+	Method     string              `json:"method"`   // "fifo" or "average"
+	Currency   string              `json:"currency"` // Base currency
+	TotalValue Money               `json:"total_value"`
+	Products   []*ProductValuation `json:"products"`
+}
+
+// ProductCOGS - Cost of goods sold for one product
+type ProductCOGS struct {
+	ProductID   string  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Quantity    float64 `json:"quantity"`
+	Cost        Money   `json:"cost"`
+}
+
+// CostOfGoodsSold - Cost of stock-outs in a period
+type CostOfGoodsSold struct {
+	StartDate string         `json:"start_date"`
+	EndDate   string         `json:"end_date"`
+	Method    string         `json:"method"`
+	Currency  string         `json:"currency"`
+	Total     Money          `json:"total"`
+	Products  []*ProductCOGS `json:"products"`
+}
+
+// costLayer - FIFO maliyet katmanı
+type costLayer struct {
+	quantity float64
+	unitCost Money
+}
+
+// valuationState - Bir ürünün yeniden oynatma durumu
+type valuationState struct {
+	productID   string
+	productName string
+	method      string
+	quantity    float64
+	value       Money       // Ortalama yöntem: eldeki toplam değer
+	layers      []costLayer // FIFO katmanları (eskiden yeniye)
+	deficit     float64     // Eksi stok miktarı (maliyeti zaten giderleşti)
+	lastCost    Money       // Son bilinen birim maliyet
+}
+
+// averageCost - Eldeki birim maliyet (ortalama yöntem)
+func (v *valuationState) averageCost() Money {
+	if v.quantity <= 0 {
+		return v.lastCost
+	}
+	return v.value.DivQuantity(v.quantity)
+}
+
+// receive - Giriş işler
+func (v *valuationState) receive(amount float64, unitCost Money) {
+	if unitCost <= 0 {
+		unitCost = v.averageCost()
+		if v.method == ValuationFIFO {
+			unitCost = v.lastCost
+		}
+	} else {
+		v.lastCost = unitCost
+	}
+
+	// Önce eksi stok açığını kapat
+	covered := amount
+	if v.deficit > 0 {
+		if covered > v.deficit {
+			covered -= v.deficit
+			v.deficit = 0
+		} else {
+			v.deficit -= covered
+			covered = 0
+		}
+	}
+
+	v.quantity += amount
+	if covered <= 0 {
+		return
+	}
+
+	if v.method == ValuationFIFO {
+		v.layers = append(v.layers, costLayer{quantity: covered, unitCost: unitCost})
+	} else {
+		v.value += unitCost.MulQuantity(covered)
+	}
+}
+
+// issue - Çıkış işler ve çıkan miktarın maliyetini döner
+func (v *valuationState) issue(amount float64) Money {
+	var cost Money
+	remaining := amount
+
+	if v.method == ValuationFIFO {
+		for remaining > 0 && len(v.layers) > 0 {
+			layer := &v.layers[0]
+			take := layer.quantity
+			if take > remaining {
+				take = remaining
+			}
+			cost += layer.unitCost.MulQuantity(take)
+			layer.quantity -= take
+			remaining -= take
+			if layer.quantity <= 1e-9 {
+				v.layers = v.layers[1:]
+			}
+		}
+	} else {
+		onHand := v.quantity
+		if onHand < 0 {
+			onHand = 0
+		}
+		take := amount
+		if take > onHand {
+			take = onHand
+		}
+		if take > 0 {
+			takeCost := v.value.MulFraction(take, onHand)
+			cost += takeCost
+			v.value -= takeCost
+			remaining -= take
+		}
+	}
+
+	// Eldekinden fazla çıkış: son bilinen maliyetle
+	if remaining > 1e-9 {
+		cost += v.lastCost.MulQuantity(remaining)
+		v.deficit += remaining
+	}
+
+	v.quantity -= amount
+	return cost
+}
+
+// onHandValue - Eldeki stok değeri
+func (v *valuationState) onHandValue() Money {
+	if v.method != ValuationFIFO {
+		return v.value
+	}
+	var value Money
+	for _, layer := range v.layers {
+		value += layer.unitCost.MulQuantity(layer.quantity)
+	}
+	return value
+}
+
+// normalizeValuationMethod - Geçersiz veya boş yöntem için ayarlardaki yöntem
+func normalizeValuationMethod(method string) string {
+	switch strings.ToLower(strings.TrimSpace(method)) {
+	case ValuationFIFO:
+		return ValuationFIFO
+	case ValuationAverage:
+		return ValuationAverage
+	}
+	return GetValuationMethod()
+}
+
+// replayValuation - Hareketleri until'a kadar (dahil) yeniden oynatır
+// onIssue her çıkış için maliyetle çağrılır (nil olabilir)
+func (s *BleveStore) replayValuation(method string, until time.Time, onIssue func(m *StockMovement, cost Money)) (map[string]*valuationState, error) {
+	movements, err := s.ListStockMovements()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.toCurrentUnits(movements); err != nil {
+		return nil, err
+	}
+
+	// Eskiden yeniye
+	sort.SliceStable(movements, func(i, j int) bool {
+		return movements[i].Date.Before(movements[j].Date)
+	})
+
+	states := make(map[string]*valuationState)
+	for _, m := range movements {
+		if !until.IsZero() && m.Date.After(until) {
+			break
+		}
+		// until'a kadar iptal edilen hareket ve karşı hareketi hiç olmamış sayılır; sonradan
+		// iptal edilen hareket until'da geçerlidir (karşı hareketi kendi tarihiyle until'dan sonra kalır)
+		if m.isReversedAt(until) || m.ReversalOf != "" {
+			continue
+		}
+
+		state, ok := states[m.ProductID]
+		if !ok {
+			state = &valuationState{productID: m.ProductID, method: method}
+			states[m.ProductID] = state
+		}
+		state.productName = m.ProductName
+
+		switch m.MovementType {
+		case MovementTypeIn:
+			state.receive(m.Amount, m.UnitCost)
+		case MovementTypeOut:
+			cost := state.issue(m.Amount)
+			if onIssue != nil {
+				onIssue(m, cost)
+			}
+		case MovementTypeAdjust:
+			// Düzeltmeler stok değerini değiştirir ama satılan malın maliyetine girmez
+			if m.Amount > 0 {
+				state.receive(m.Amount, m.UnitCost)
+			} else {
+				state.issue(-m.Amount)
+			}
+		}
+	}
+
+	return states, nil
+}
+
+// GetInventoryValuation - Quantity and value per product at the given time
+func (s *BleveStore) GetInventoryValuation(at time.Time, method string) (*InventoryValuation, error) {
+	method = normalizeValuationMethod(method)
+
+	states, err := s.replayValuation(method, at, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &InventoryValuation{
+		Date:     at.Format("2006-01-02"),
+		Method:   method,
+		Currency: GetBaseCurrency(),
+		Products: []*ProductValuation{},
+	}
+
+	for _, state := range states {
+		if state.quantity == 0 {
+			continue
+		}
+
+		pv := &ProductValuation{
+			ProductID:   state.productID,
+			ProductName: state.productName,
+			Quantity:    state.quantity,
+			Value:       state.onHandValue(),
+		}
+		if pv.Quantity > 0 {
+			pv.UnitCost = pv.Value.DivQuantity(pv.Quantity)
+		}
+
+		result.TotalValue += pv.Value
+		result.Products = append(result.Products, pv)
+	}
+
+	sort.Slice(result.Products, func(i, j int) bool {
+		return result.Products[i].Value > result.Products[j].Value
+	})
+
+	return result, nil
+}
+
+// GetCostOfGoodsSold - Cost of stock-outs between start and end (inclusive)
+func (s *BleveStore) GetCostOfGoodsSold(start, end time.Time, method string) (*CostOfGoodsSold, error) {
+	if end.Before(start) {
+		return nil, fmt.Errorf("end date is before start date")
+	}
+	method = normalizeValuationMethod(method)
+
+	result := &CostOfGoodsSold{
+		StartDate: start.Format("2006-01-02"),
+		EndDate:   end.Format("2006-01-02"),
+		Method:    method,
+		Currency:  GetBaseCurrency(),
+		Products:  []*ProductCOGS{},
+	}
+
+	byProduct := make(map[string]*ProductCOGS)
+	_, err := s.replayValuation(method, end, func(m *StockMovement, cost Money) {
+		if m.Date.Before(start) {
+			return
+		}
+
+		pc, ok := byProduct[m.ProductID]
+		if !ok {
+			pc = &ProductCOGS{ProductID: m.ProductID, ProductName: m.ProductName}
+			byProduct[m.ProductID] = pc
+			result.Products = append(result.Products, pc)
+		}
+		pc.Quantity += m.Amount
+		pc.Cost += cost
+		result.Total += cost
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result.Products, func(i, j int) bool {
+		return result.Products[i].Cost > result.Products[j].Cost
+	})
+
+	return result, nil
+}
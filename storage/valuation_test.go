@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+// saveTestMovements - Hareketleri verilen tarihlerle doğrudan deftere yazar
+func saveTestMovements(t *testing.T, s *BleveStore, movements ...*StockMovement) {
+	t.Helper()
+	for _, m := range movements {
+		m.ProductID = "p1"
+		m.ProductName = "Yağ filtresi"
+		if err := s.SaveStockMovement(m); err != nil {
+			t.Fatalf("SaveStockMovement: %v", err)
+		}
+	}
+}
+
+func testDay(day int) time.Time {
+	return time.Date(2024, 3, day, 12, 0, 0, 0, time.Local)
+}
+
+func TestReplayValuationMethods(t *testing.T) {
+	s := newTestStore(t)
+	saveTestMovements(t, s,
+		&StockMovement{ID: "in1", MovementType: MovementTypeIn, Amount: 10, UnitCost: 1000, Date: testDay(1)},
+		&StockMovement{ID: "in2", MovementType: MovementTypeIn, Amount: 10, UnitCost: 2000, Date: testDay(2)},
+		&StockMovement{ID: "out1", MovementType: MovementTypeOut, Amount: 15, Date: testDay(3)},
+		&StockMovement{ID: "adj1", MovementType: MovementTypeAdjust, Amount: -1, Date: testDay(4)},
+	)
+
+	tests := []struct {
+		method    string
+		at        time.Time
+		wantQty   float64
+		wantValue Money
+		wantCOGS  Money // 1-3 Mart
+	}{
+		{ValuationFIFO, testDay(2), 20, 30000, 20000},
+		{ValuationFIFO, testDay(3), 5, 10000, 20000},
+		{ValuationFIFO, testDay(4), 4, 8000, 20000},
+		{ValuationAverage, testDay(2), 20, 30000, 22500},
+		{ValuationAverage, testDay(3), 5, 7500, 22500},
+		{ValuationAverage, testDay(4), 4, 6000, 22500},
+	}
+
+	for _, tt := range tests {
+		valuation, err := s.GetInventoryValuation(tt.at, tt.method)
+		if err != nil {
+			t.Fatalf("GetInventoryValuation: %v", err)
+		}
+		if len(valuation.Products) != 1 {
+			t.Fatalf("%s %s: %d products, want 1", tt.method, tt.at.Format("2006-01-02"), len(valuation.Products))
+		}
+		pv := valuation.Products[0]
+		if pv.Quantity != tt.wantQty || pv.Value != tt.wantValue {
+			t.Errorf("%s %s: quantity %v value %d, want %v and %d",
+				tt.method, tt.at.Format("2006-01-02"), pv.Quantity, pv.Value, tt.wantQty, tt.wantValue)
+		}
+
+		cogs, err := s.GetCostOfGoodsSold(testDay(1), tt.at, tt.method)
+		if err != nil {
+			t.Fatalf("GetCostOfGoodsSold: %v", err)
+		}
+		want := tt.wantCOGS
+		if tt.at.Before(testDay(3)) {
+			want = 0
+		}
+		if cogs.Total != want {
+			t.Errorf("%s COGS until %s = %d, want %d", tt.method, tt.at.Format("2006-01-02"), cogs.Total, want)
+		}
+	}
+}
+
+func TestReplayValuationReversedLater(t *testing.T) {
+	s := newTestStore(t)
+	reversedAt := testDay(4)
+	saveTestMovements(t, s,
+		&StockMovement{ID: "in1", MovementType: MovementTypeIn, Amount: 10, UnitCost: 1000, Date: testDay(1)},
+		&StockMovement{ID: "out1", MovementType: MovementTypeOut, Amount: 4, Date: testDay(2), ReversedBy: "rev1", ReversedAt: &reversedAt},
+		&StockMovement{ID: "rev1", MovementType: MovementTypeIn, Amount: 4, Date: reversedAt, ReversalOf: "out1"},
+	)
+
+	tests := []struct {
+		at       time.Time
+		wantQty  float64
+		wantCOGS Money
+	}{
+		{testDay(3), 6, 4000}, // Çıkış henüz iptal edilmemişti
+		{testDay(4), 10, 0},   // İptal günü: çift hiç olmamış sayılır
+		{time.Time{}, 10, 0},  // Güncel durum
+	}
+
+	for _, tt := range tests {
+		for _, method := range []string{ValuationFIFO, ValuationAverage} {
+			valuation, err := s.GetInventoryValuation(tt.at, method)
+			if err != nil {
+				t.Fatalf("GetInventoryValuation: %v", err)
+			}
+			if len(valuation.Products) != 1 || valuation.Products[0].Quantity != tt.wantQty {
+				t.Errorf("%s at %v: products %+v, want quantity %v", method, tt.at, valuation.Products, tt.wantQty)
+			}
+
+			if tt.at.IsZero() {
+				continue
+			}
+			cogs, err := s.GetCostOfGoodsSold(testDay(1), tt.at, method)
+			if err != nil {
+				t.Fatalf("GetCostOfGoodsSold: %v", err)
+			}
+			if cogs.Total != tt.wantCOGS {
+				t.Errorf("%s COGS until %v = %d, want %d", method, tt.at, cogs.Total, tt.wantCOGS)
+			}
+		}
+	}
+}
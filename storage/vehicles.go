@@ -0,0 +1,327 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// ============================================
+// Araçlar
+// Müşteriye ait araç kaydı (plaka, şasi no, marka, model, yıl, motor kodu, km).
+// Siparişler ve stok çıkışları araca bağlanabilir; plaka siparişte ve harekette
+// denormalize tutulur, böylece araç silinse de geçmişte görünür. Plakalar
+// boşluk ve tire olmadan büyük harfle saklanır ("34 abc 123" -> "34ABC123").
+// ============================================
+
+const vehiclesDir = "vehicles"
+
+// Vehicle - A customer's vehicle
+type Vehicle struct {
+	ID           string     `json:"id"`
+	CustomerID   string     `json:"customer_id"`
+	CustomerName string     `json:"customer_name"` // Denormalize
+	Plate        string     `json:"plate"`         // Normalized, unique
+	VIN          string     `json:"vin"`           // Şasi numarası
+	Make         string     `json:"make"`
+	Model        string     `json:"model"`
+	Year         int        `json:"year"`
+	EngineCode   string     `json:"engine_code"`
+	Mileage      int        `json:"mileage"`                // Son bilinen km
+	MileageDate  *time.Time `json:"mileage_date,omitempty"` // Km'nin kaydedildiği tarih
+	Notes        string     `json:"notes"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// VehicleHistory - Service history of a vehicle
+type VehicleHistory struct {
+	Vehicle    *Vehicle         `json:"vehicle"`
+	Orders     []*Order         `json:"orders"`    // Newest first
+	Movements  []*StockMovement `json:"movements"` // Stock-outs not tied to an order, newest first
+	VisitCount int              `json:"visit_count"`
+	TotalSpent Money            `json:"total_spent"` // Sale orders, base currency
+	LastVisit  *time.Time       `json:"last_visit,omitempty"`
+}
+
+// normalizePlate - Plakayı büyük harfe çevirir, boşluk ve ayırıcıları atar
+func normalizePlate(plate string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(plate) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// prepareVehicle - Müşteriyi ve alanları doğrular, plakanın tekil olduğunu kontrol eder
+func (s *BleveStore) prepareVehicle(vehicle *Vehicle) error {
+	vehicle.Plate = normalizePlate(vehicle.Plate)
+	if vehicle.Plate == "" {
+		return fmt.Errorf("plaka gerekli")
+	}
+	vehicle.VIN = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(vehicle.VIN), " ", ""))
+	vehicle.Make = strings.TrimSpace(vehicle.Make)
+	vehicle.Model = strings.TrimSpace(vehicle.Model)
+	vehicle.EngineCode = strings.ToUpper(strings.TrimSpace(vehicle.EngineCode))
+	vehicle.Notes = strings.TrimSpace(vehicle.Notes)
+
+	if vehicle.Year != 0 && (vehicle.Year < 1900 || vehicle.Year > time.Now().Year()+1) {
+		return fmt.Errorf("geçersiz model yılı: %d", vehicle.Year)
+	}
+	if vehicle.Mileage < 0 {
+		return fmt.Errorf("km negatif olamaz")
+	}
+
+	customer, err := s.GetCustomer(vehicle.CustomerID)
+	if err != nil {
+		return err
+	}
+	vehicle.CustomerName = customer.Name
+
+	vehicles, err := s.ListVehicles("")
+	if err != nil {
+		return err
+	}
+	for _, other := range vehicles {
+		if other.ID == vehicle.ID {
+			continue
+		}
+		if other.Plate == vehicle.Plate {
+			return fmt.Errorf("%s plakalı araç zaten kayıtlı (%s)", vehicle.Plate, other.CustomerName)
+		}
+		if vehicle.VIN != "" && other.VIN == vehicle.VIN {
+			return fmt.Errorf("%s şasi numaralı araç zaten kayıtlı (%s)", vehicle.VIN, other.Plate)
+		}
+	}
+
+	return nil
+}
+
+// CreateVehicle - Registers a vehicle for a customer
+func (s *BleveStore) CreateVehicle(vehicle *Vehicle) (*Vehicle, error) {
+	vehicle.ID = uuid.New().String()
+	if err := s.prepareVehicle(vehicle); err != nil {
+		return nil, err
+	}
+
+	vehicle.CreatedAt = time.Now()
+	vehicle.UpdatedAt = vehicle.CreatedAt
+	if vehicle.Mileage > 0 {
+		vehicle.MileageDate = &vehicle.CreatedAt
+	}
+
+	if err := s.writeJSONFile(vehiclesDir, vehicle.ID, vehicle); err != nil {
+		return nil, err
+	}
+	return vehicle, nil
+}
+
+// UpdateVehicle - Updates a vehicle (also used to change its owner)
+func (s *BleveStore) UpdateVehicle(vehicle *Vehicle) (*Vehicle, error) {
+	existing, err := s.GetVehicle(vehicle.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.prepareVehicle(vehicle); err != nil {
+		return nil, err
+	}
+
+	vehicle.CreatedAt = existing.CreatedAt
+	vehicle.UpdatedAt = time.Now()
+	vehicle.MileageDate = existing.MileageDate
+	if vehicle.Mileage != existing.Mileage {
+		vehicle.MileageDate = &vehicle.UpdatedAt
+	}
+
+	if err := s.writeJSONFile(vehiclesDir, vehicle.ID, vehicle); err != nil {
+		return nil, err
+	}
+	return vehicle, nil
+}
+
+// DeleteVehicle - Deletes a vehicle without service history
+func (s *BleveStore) DeleteVehicle(id string) error {
+	history, err := s.GetVehicleHistory(id)
+	if err != nil {
+		return err
+	}
+	if len(history.Orders) > 0 || len(history.Movements) > 0 {
+		return fmt.Errorf("servis geçmişi olan araç silinemez")
+	}
+	return s.removeJSONFile(vehiclesDir, id)
+}
+
+// GetVehicle - Returns a vehicle by ID
+func (s *BleveStore) GetVehicle(id string) (*Vehicle, error) {
+	var vehicle Vehicle
+	if err := s.readJSONFile(vehiclesDir, id, &vehicle); err != nil {
+		return nil, fmt.Errorf("araç bulunamadı: %s", id)
+	}
+	return &vehicle, nil
+}
+
+// ListVehicles - Vehicles sorted by plate; customerID is an optional filter
+func (s *BleveStore) ListVehicles(customerID string) ([]*Vehicle, error) {
+	ids, err := s.listJSONFileIDs(vehiclesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	vehicles := []*Vehicle{}
+	for _, id := range ids {
+		var vehicle Vehicle
+		if err := s.readJSONFile(vehiclesDir, id, &vehicle); err != nil {
+			continue
+		}
+		if customerID != "" && vehicle.CustomerID != customerID {
+			continue
+		}
+		vehicles = append(vehicles, &vehicle)
+	}
+
+	sort.Slice(vehicles, func(i, j int) bool {
+		return vehicles[i].Plate < vehicles[j].Plate
+	})
+
+	return vehicles, nil
+}
+
+// SearchVehicles - Vehicles whose plate, VIN, make, model or owner matches the term
+// Plates match regardless of spaces and case ("34 abc" finds "34ABC123").
+func (s *BleveStore) SearchVehicles(term string) ([]*Vehicle, error) {
+	vehicles, err := s.ListVehicles("")
+	if err != nil {
+		return nil, err
+	}
+
+	plate := normalizePlate(term)
+	text := strings.ToLower(strings.TrimSpace(term))
+	if text == "" {
+		return vehicles, nil
+	}
+
+	result := []*Vehicle{}
+	for _, v := range vehicles {
+		if plate != "" && (strings.Contains(v.Plate, plate) || strings.Contains(v.VIN, plate)) {
+			result = append(result, v)
+			continue
+		}
+		fields := strings.ToLower(v.Make + " " + v.Model + " " + v.CustomerName)
+		if strings.Contains(fields, text) {
+			result = append(result, v)
+		}
+	}
+
+	// Plakası terimle başlayanlar önce
+	sort.SliceStable(result, func(i, j int) bool {
+		return strings.HasPrefix(result[i].Plate, plate) && !strings.HasPrefix(result[j].Plate, plate)
+	})
+
+	return result, nil
+}
+
+// applyOrderVehicle - Siparişin aracını doğrular ve plakayı denormalize eder
+// Müşterisi boş siparişe aracın sahibi atanır; farklı müşterinin aracı seçilemez.
+// existing verilirse ve araç ile müşteri değişmediyse sahiplik yeniden denetlenmez
+// (araç sonradan başka müşteriye geçmiş olabilir).
+func (s *BleveStore) applyOrderVehicle(order *Order, existing *Order) error {
+	if order.VehicleID == "" {
+		order.VehiclePlate = ""
+		return nil
+	}
+	if existing != nil && existing.VehicleID == order.VehicleID && existing.CustomerID == order.CustomerID {
+		order.VehiclePlate = existing.VehiclePlate
+		return nil
+	}
+
+	vehicle, err := s.GetVehicle(order.VehicleID)
+	if err != nil {
+		return err
+	}
+	if order.CustomerID == "" {
+		order.CustomerID = vehicle.CustomerID
+		order.CustomerName = vehicle.CustomerName
+	} else if order.CustomerID != vehicle.CustomerID {
+		return fmt.Errorf("%s plakalı araç bu müşteriye ait değil (%s)", vehicle.Plate, vehicle.CustomerName)
+	}
+	order.VehiclePlate = vehicle.Plate
+	return nil
+}
+
+// recordVehicleMileage - Siparişteki km aracın son bilinen km'sinden büyükse araca yazar
+func (s *BleveStore) recordVehicleMileage(vehicleID string, mileage int, date time.Time) error {
+	if vehicleID == "" || mileage <= 0 {
+		return nil
+	}
+
+	vehicle, err := s.GetVehicle(vehicleID)
+	if err != nil {
+		return err
+	}
+	if mileage <= vehicle.Mileage {
+		return nil
+	}
+
+	vehicle.Mileage = mileage
+	vehicle.MileageDate = &date
+	vehicle.UpdatedAt = time.Now()
+	return s.writeJSONFile(vehiclesDir, vehicle.ID, vehicle)
+}
+
+// GetVehicleHistory - Orders and direct stock-outs of a vehicle
+func (s *BleveStore) GetVehicleHistory(vehicleID string) (*VehicleHistory, error) {
+	vehicle, err := s.GetVehicle(vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &VehicleHistory{
+		Vehicle:   vehicle,
+		Orders:    []*Order{},
+		Movements: []*StockMovement{},
+	}
+
+	orders, err := s.ListOrders()
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if order.VehicleID != vehicleID {
+			continue
+		}
+		history.Orders = append(history.Orders, order)
+		if !order.isSale() {
+			continue
+		}
+		history.VisitCount++
+		history.TotalSpent += order.BaseGrandTotal
+		if date := order.saleDate(); history.LastVisit == nil || date.After(*history.LastVisit) {
+			history.LastVisit = &date
+		}
+	}
+	sort.Slice(history.Orders, func(i, j int) bool {
+		return history.Orders[i].CreatedAt.After(history.Orders[j].CreatedAt)
+	})
+
+	movements, err := s.ListStockMovements()
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range movements {
+		if m.VehicleID != vehicleID || m.OrderID != "" || m.IsReversed() || m.ReversalOf != "" {
+			continue
+		}
+		history.Movements = append(history.Movements, m)
+	}
+	sort.Slice(history.Movements, func(i, j int) bool {
+		return history.Movements[i].Date.After(history.Movements[j].Date)
+	})
+
+	return history, nil
+}
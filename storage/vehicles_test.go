@@ -0,0 +1,58 @@
+package storage
+
+import "testing"
+
+func TestUpdateOrderAfterVehicleOwnerChange(t *testing.T) {
+	s := newTestStore(t)
+
+	seller, buyer := NewCustomer("Eski Sahip"), NewCustomer("Yeni Sahip")
+	for _, c := range []*Customer{seller, buyer} {
+		if err := s.SaveCustomer(c); err != nil {
+			t.Fatalf("SaveCustomer: %v", err)
+		}
+	}
+
+	vehicle, err := s.CreateVehicle(&Vehicle{CustomerID: seller.ID, Plate: "34 ABC 123"})
+	if err != nil {
+		t.Fatalf("CreateVehicle: %v", err)
+	}
+
+	order := NewOrderWithCustomer(seller.ID, seller.Name)
+	order.VehicleID = vehicle.ID
+	order.Items = append(order.Items, NewOrderItem("İşçilik", "", 1, 50000, "original"))
+	if err := s.SaveOrder(order); err != nil {
+		t.Fatalf("SaveOrder: %v", err)
+	}
+
+	vehicle.CustomerID = buyer.ID
+	if _, err := s.UpdateVehicle(vehicle); err != nil {
+		t.Fatalf("UpdateVehicle: %v", err)
+	}
+
+	// Araç ve müşteri değişmeden eski sipariş yeniden kaydedilebilmeli
+	order.Title = "Eski bakım"
+	if err := s.UpdateOrder(order); err != nil {
+		t.Fatalf("UpdateOrder on unchanged vehicle: %v", err)
+	}
+	if order.VehiclePlate != "34ABC123" {
+		t.Errorf("VehiclePlate = %q, want 34ABC123", order.VehiclePlate)
+	}
+
+	// Yeni sipariş aracı yalnızca yeni sahibi için kullanabilir
+	other := NewOrderWithCustomer(seller.ID, seller.Name)
+	other.VehicleID = vehicle.ID
+	other.Items = append(other.Items, NewOrderItem("İşçilik", "", 1, 50000, "original"))
+	if err := s.SaveOrder(other); err == nil {
+		t.Error("SaveOrder with the previous owner's customer succeeded, want error")
+	}
+
+	// Müşterisi değişen eski sipariş yeniden denetlenir
+	third := NewCustomer("Başka Müşteri")
+	if err := s.SaveCustomer(third); err != nil {
+		t.Fatalf("SaveCustomer: %v", err)
+	}
+	order.CustomerID, order.CustomerName = third.ID, third.Name
+	if err := s.UpdateOrder(order); err == nil {
+		t.Error("UpdateOrder moving the order to a non-owner succeeded, want error")
+	}
+}
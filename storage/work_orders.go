@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ============================================
+// İş Emirleri (Servis)
+// Sipariş kalemi parça, işçilik veya hizmet olabilir. İşçilik kaleminde miktar
+// saat, birim fiyat saat ücretidir; hizmet kalemi sabit fiyatlı iştir (örn. rot
+// ayarı). Parça dışındaki kalemler katalogla eşleşmez, stoktan düşmez, stok
+// ayırmaz ve maliyeti yoktur; ama toplamlara, KDV'ye ve raporlara girer.
+// Araçlı siparişler iş emri olarak geliş/çıkış km'si, şikayet ve yapılan işlemi tutar.
+// ============================================
+
+// Order item line types
+const (
+	LineTypePart    = "part"    // Parça (boş = parça, eski kayıtlar)
+	LineTypeLabor   = "labor"   // İşçilik (saat × saat ücreti)
+	LineTypeService = "service" // Hizmet (sabit fiyat)
+)
+
+// Tax categories of non-part lines (KDV oranı kategori ayarlarından gelir)
+const (
+	TaxCategoryLabor   = "İşçilik"
+	TaxCategoryService = "Hizmet"
+)
+
+// IsPart - Kalem parça mı (stoktan düşen, maliyeti olan kalem)
+func (item *OrderItem) IsPart() bool {
+	return item.LineType == "" || item.LineType == LineTypePart
+}
+
+// prepareServiceLine - İşçilik / hizmet kalemini hazırlar; vergi kategorisini döner
+// Saat girildiyse miktar saattir, saat ücreti girildiyse birim fiyattır.
+func (item *OrderItem) prepareServiceLine() (string, error) {
+	item.ProductID = ""
+	item.OEMNumber = ""
+	item.PartStatus = ""
+	item.Unit = ""
+	item.BaseQuantity = 0
+	item.UnitCost = 0
+	item.SerialNumbers = nil
+	item.Technician = strings.TrimSpace(item.Technician)
+
+	switch item.LineType {
+	case LineTypeLabor:
+		if item.Hours < 0 || item.HourlyRate < 0 {
+			return "", fmt.Errorf("%s: saat ve saat ücreti negatif olamaz", item.ProductName)
+		}
+		if item.Hours > 0 {
+			item.Quantity = item.Hours
+		}
+		if item.HourlyRate > 0 {
+			item.UnitPrice = item.HourlyRate
+		}
+		item.Hours = item.Quantity
+		item.HourlyRate = item.UnitPrice
+	case LineTypeService:
+		item.Hours = 0
+		item.HourlyRate = 0
+	default:
+		return "", fmt.Errorf("%s: geçersiz kalem türü: %q", item.ProductName, item.LineType)
+	}
+
+	if item.Quantity <= 0 {
+		return "", fmt.Errorf("%s: miktar sıfırdan büyük olmalı", item.ProductName)
+	}
+	if math.Round(item.Quantity*quantityScale) != item.Quantity*quantityScale {
+		return "", fmt.Errorf("%s: miktar en fazla 3 ondalık olabilir", item.ProductName)
+	}
+
+	if item.LineType == LineTypeLabor {
+		return TaxCategoryLabor, nil
+	}
+	return TaxCategoryService, nil
+}
+
+// calculateLineTypeTotals - Parça ve işçilik/hizmet ara toplamları (KDV hariç, sipariş para birimi)
+func (order *Order) calculateLineTypeTotals() {
+	order.PartsTotal = 0
+	order.LaborTotal = 0
+	for i := range order.Items {
+		item := &order.Items[i]
+		if item.IsPart() {
+			order.PartsTotal += item.NetAmount
+		} else {
+			order.LaborTotal += item.NetAmount
+		}
+	}
+}
+
+// prepareWorkOrder - İş emri alanlarını doğrular
+func (order *Order) prepareWorkOrder() error {
+	if order.MileageIn < 0 || order.MileageOut < 0 {
+		return fmt.Errorf("km negatif olamaz")
+	}
+	if order.MileageIn > 0 && order.MileageOut > 0 && order.MileageOut < order.MileageIn {
+		return fmt.Errorf("çıkış km'si (%d) geliş km'sinden (%d) küçük olamaz", order.MileageOut, order.MileageIn)
+	}
+	order.Complaint = strings.TrimSpace(order.Complaint)
+	order.WorkPerformed = strings.TrimSpace(order.WorkPerformed)
+	return nil
+}
+
+// lastMileage - İş emrindeki en son km (çıkış, yoksa geliş)
+func (order *Order) lastMileage() int {
+	if order.MileageOut > 0 {
+		return order.MileageOut
+	}
+	return order.MileageIn
+}